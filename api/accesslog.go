@@ -0,0 +1,238 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// redactedValue replaces the values of redacted fields in logged bodies.
+const redactedValue = "[REDACTED]"
+
+// logResponseWriter records the status code and size of the response, and
+// keeps its body when the body is logged.
+type logResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	bytes      int64
+	// body is nil when the body is not logged.
+	body *bodyCapture
+}
+
+func newLogResponseWriter(w http.ResponseWriter, body *bodyCapture) *logResponseWriter {
+	return &logResponseWriter{ResponseWriter: w, body: body}
+}
+
+func (lrw *logResponseWriter) WriteHeader(code int) {
+	lrw.statusCode = code
+	lrw.ResponseWriter.WriteHeader(code)
+}
+
+func (lrw *logResponseWriter) Write(b []byte) (int, error) {
+	if lrw.statusCode == 0 {
+		lrw.statusCode = http.StatusOK
+	}
+	n, err := lrw.ResponseWriter.Write(b)
+	lrw.bytes += int64(n)
+	lrw.body.write(b[:n])
+	return n, err
+}
+
+// Flush sends the buffered response, if the wrapped writer can.
+func (lrw *logResponseWriter) Flush() {
+	if f, ok := lrw.ResponseWriter.(http.Flusher); ok {
+		if lrw.statusCode == 0 {
+			lrw.statusCode = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over to the caller, if the wrapped writer can.
+// Whatever is written to the connection afterwards is not counted.
+func (lrw *logResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := lrw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+	if lrw.statusCode == 0 {
+		lrw.statusCode = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the wrapped writer.
+func (lrw *logResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
+
+// bodyCapture keeps the first max bytes of a body.
+type bodyCapture struct {
+	max       int
+	buf       []byte
+	truncated bool
+}
+
+func newBodyCapture(max int) *bodyCapture {
+	return &bodyCapture{max: max}
+}
+
+func (c *bodyCapture) write(b []byte) {
+	if c == nil || c.truncated {
+		return
+	}
+	if len(c.buf)+len(b) > c.max {
+		c.truncated = true
+		c.buf = nil
+		return
+	}
+	c.buf = append(c.buf, b...)
+}
+
+// countingReader counts the bytes read from a request body, and keeps them
+// when the body is logged.
+type countingReader struct {
+	io.ReadCloser
+	bytes int64
+	// body is nil when the body is not logged.
+	body *bodyCapture
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.ReadCloser.Read(p)
+	cr.bytes += int64(n)
+	cr.body.write(p[:n])
+	return n, err
+}
+
+// loggingHandlerWrapper logs a line for every request, with the byte counts
+// of both bodies. The bodies themselves are logged for a sample of requests,
+// see config.AccessLog.
+func (s *Server) loggingHandlerWrapper(wrappedHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody, resBody *bodyCapture
+		if s.accessLog.BodySampleRate > 0 && rand.Float64() < s.accessLog.BodySampleRate {
+			reqBody = newBodyCapture(s.accessLog.MaxBodyBytes)
+			resBody = newBodyCapture(s.accessLog.MaxBodyBytes)
+		}
+		var reqBytes *countingReader
+		if r.Body != nil && r.Body != http.NoBody {
+			reqBytes = &countingReader{ReadCloser: r.Body, body: reqBody}
+			r.Body = reqBytes
+		}
+
+		start := s.Time.Now()
+		lrw := newLogResponseWriter(w, resBody)
+		wrappedHandler.ServeHTTP(lrw, r)
+		duration := s.Time.Now().Sub(start)
+
+		if s.skipAccessLog(r) {
+			return
+		}
+
+		fields := logrus.Fields{
+			"duration":      duration.String(),
+			"statusCode":    lrw.statusCode,
+			"uri":           r.RequestURI,
+			"method":        r.Method,
+			"requestBytes":  int64(0),
+			"responseBytes": lrw.bytes,
+		}
+		if reqBytes != nil {
+			fields["requestBytes"] = reqBytes.bytes
+		}
+
+		if v, ok := s.loggedBody(reqBody, r.Header.Get("Content-Type")); ok {
+			fields["request"] = v
+		}
+		if v, ok := s.loggedBody(resBody, lrw.Header().Get("Content-Type")); ok {
+			fields["response"] = v
+		}
+
+		if caller := CallerFromContext(r.Context()); caller != nil {
+			fields["caller"] = caller.Name
+		}
+
+		s.logger(r.Context()).
+			WithFields(fields).
+			Log(s.accessLogLevel(), "Request handling took: ", duration)
+	})
+}
+
+// skipAccessLog reports whether r is left out of the access log, because of
+// its route or because it is a health check.
+func (s *Server) skipAccessLog(r *http.Request) bool {
+	if s.skipHealthCheckLogging && (r.URL.Path == s.statusPath || strings.HasPrefix(r.URL.Path, s.statusPath+"/")) {
+		return true
+	}
+
+	tpl := routeTemplate(r)
+	for _, skipped := range s.accessLog.SkipRoutes {
+		if tpl == skipped {
+			return true
+		}
+	}
+	return false
+}
+
+// accessLogLevel returns the level of the access log lines. The zero level
+// is logrus.PanicLevel, which panics after logging, so it and
+// logrus.FatalLevel fall back to info.
+func (s *Server) accessLogLevel() logrus.Level {
+	if s.accessLog.Level <= logrus.FatalLevel {
+		return logrus.InfoLevel
+	}
+	return s.accessLog.Level
+}
+
+// loggedBody returns the captured body c with its redacted fields replaced,
+// and false when there is nothing to log. Only JSON bodies are logged, and
+// bodies over the size cap are replaced by a note, as they cannot be
+// redacted.
+func (s *Server) loggedBody(c *bodyCapture, contentType string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	if !compareContentTypes(contentType, "application/json") && !compareContentTypes(contentType, problemContentType) {
+		return nil, false
+	}
+	if c.truncated {
+		return fmt.Sprintf("omitted, larger than %d bytes", c.max), true
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(c.buf, &v); err != nil {
+		return nil, false
+	}
+	return redactFields(v, s.accessLog.RedactFields), true
+}
+
+// redactFields replaces the values of the fields of v named in fields,
+// at any depth.
+func redactFields(v interface{}, fields []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, fv := range v {
+			v[k] = redactFields(fv, fields)
+			for _, f := range fields {
+				if strings.EqualFold(k, f) {
+					v[k] = redactedValue
+					break
+				}
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactFields(item, fields)
+		}
+	}
+	return v
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"packs-api/internal/config"
+	"packs-api/internal/utils"
+)
+
+func TestServer_LoggingHandlerWrapper(t *testing.T) {
+	var out bytes.Buffer
+	logger := utils.NewLogger("test", "packs-api")
+	logger.Logger.SetOutput(&out)
+
+	s := new(Server)
+	s.Log = logger
+	s.Time = utils.NewRealTime()
+
+	router := mux.NewRouter()
+	router.Use(s.loggingHandlerWrapper)
+	router.HandleFunc("/api/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		s.writeJSONData(w, http.StatusOK, map[string]interface{}{
+			"items":   10,
+			"callers": []interface{}{map[string]interface{}{"name": "shop", "apiKey": "k-123"}},
+		})
+	}).Methods(http.MethodPost)
+
+	const resBody = `{"data":{"callers":[{"apiKey":"k-123","name":"shop"}],"items":10}}`
+
+	tests := []struct {
+		name      string
+		accessLog config.AccessLog
+		logged    bool
+		request   interface{}
+		response  interface{}
+	}{
+		{
+			"no bodies", config.AccessLog{}, true, nil, nil,
+		},
+		{
+			"redacted bodies",
+			config.AccessLog{BodySampleRate: 1, MaxBodyBytes: 1024, RedactFields: []string{"password", "APIKEY"}},
+			true,
+			map[string]interface{}{"items": float64(10), "password": redactedValue},
+			map[string]interface{}{"data": map[string]interface{}{
+				"items":   float64(10),
+				"callers": []interface{}{map[string]interface{}{"name": "shop", "apiKey": redactedValue}},
+			}},
+		},
+		{
+			"response over the cap",
+			config.AccessLog{BodySampleRate: 1, MaxBodyBytes: 40},
+			true,
+			map[string]interface{}{"items": float64(10), "password": "hunter2"},
+			"omitted, larger than 40 bytes",
+		},
+		{
+			"skipped route", config.AccessLog{SkipRoutes: []string{"/api/orders/{id}"}}, false, nil, nil,
+		},
+		{
+			"level below the logger's", config.AccessLog{Level: logrus.DebugLevel}, false, nil, nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.accessLog = tt.accessLog
+			out.Reset()
+
+			reqBody := `{"items": 10, "password": "hunter2"}`
+			req, _ := http.NewRequest(http.MethodPost, "/api/orders/1", strings.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !tt.logged {
+				assert.Empty(t, out.String())
+				return
+			}
+
+			var entry map[string]interface{}
+			assert.Nil(t, json.Unmarshal(out.Bytes(), &entry))
+			assert.Equal(t, float64(200), entry["statusCode"])
+			assert.Equal(t, float64(len(reqBody)), entry["requestBytes"])
+			assert.Equal(t, float64(len(resBody)), entry["responseBytes"])
+			assert.Equal(t, tt.request, entry["request"])
+			assert.Equal(t, tt.response, entry["response"])
+		})
+	}
+}
+
+func TestLogResponseWriter_Flush(t *testing.T) {
+	rr := httptest.NewRecorder()
+	lrw := newLogResponseWriter(rr, nil)
+
+	assert.Nil(t, http.NewResponseController(lrw).Flush())
+	assert.True(t, rr.Flushed)
+	assert.Equal(t, http.StatusOK, lrw.statusCode)
+
+	// httptest.ResponseRecorder cannot be hijacked
+	_, _, err := lrw.Hijack()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"packs-api/internal/auth"
+	"packs-api/internal/store"
+	"packs-api/internal/utils"
+)
+
+const apiKeyHeader = "X-API-KEY"
+
+// Caller is the authenticated client of a request. ID is the API key's ID or
+// the token's subject.
+type Caller struct {
+	ID   string
+	Name string
+	Role auth.Role
+}
+
+// withCaller returns a copy of ctx that carries the caller.
+func withCaller(ctx context.Context, caller *Caller) context.Context {
+	return context.WithValue(ctx, callerContextKey, caller)
+}
+
+// CallerFromContext returns the caller stored by the authentication
+// middleware, or nil for unauthenticated requests.
+func CallerFromContext(ctx context.Context) *Caller {
+	caller, _ := ctx.Value(callerContextKey).(*Caller)
+	return caller
+}
+
+// authenticate identifies the caller by an Authorization bearer token checked
+// by verifier, or by an X-API-KEY header looked up in apiKeys. A nil verifier
+// or store disables that method. Requests without valid credentials get a
+// 401, except for paths at or below exemptPath.
+func (s *Server) authenticate(apiKeys store.NoSQLStore, verifier *auth.Verifier, exemptPath string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == exemptPath || strings.HasPrefix(r.URL.Path, exemptPath+"/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if verifier != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+
+			var caller *Caller
+			token, hasToken := bearerToken(r)
+			key := r.Header.Get(apiKeyHeader)
+			switch {
+			case hasToken && verifier != nil:
+				claims, err := verifier.Verify(token)
+				if err != nil {
+					s.rejectRequest(w, r, "invalid bearer token", err)
+					return
+				}
+				caller = &Caller{ID: claims.Subject, Name: claims.Name, Role: auth.HighestRole(claims.Roles)}
+				if caller.Name == "" {
+					caller.Name = claims.Subject
+				}
+			case key != "" && apiKeys != nil:
+				apiKey, err := apiKeys.GetAPIKey(r.Context(), utils.HashAPIKey(key))
+				if errors.Is(err, store.ErrNotFound) {
+					s.rejectRequest(w, r, "invalid API key", nil)
+					return
+				}
+				if err != nil {
+					s.writeInternalError(w, r, fmt.Errorf("error getting API key: %w", err), "failed to authenticate request")
+					return
+				}
+				caller = &Caller{ID: apiKey.ID.Hex(), Name: apiKey.Name, Role: auth.ParseRole(apiKey.Role)}
+			default:
+				s.rejectRequest(w, r, "missing credentials", nil)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(withCaller(r.Context(), caller)))
+		})
+	}
+}
+
+// bearerToken returns the token of an Authorization header with the Bearer
+// scheme.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// requireRole lets only callers with at least the given role through to next.
+// It does nothing when authentication is disabled.
+func (s *Server) requireRole(role auth.Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authEnabled {
+			next(w, r)
+			return
+		}
+
+		caller := CallerFromContext(r.Context())
+		if caller == nil {
+			s.rejectRequest(w, r, "missing credentials", nil)
+			return
+		}
+		if caller.Role < role {
+			s.writeError(w, r, problemForbidden, fmt.Sprintf("%s role required", role))
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// rejectRequest logs the failed attempt and writes a 401.
+func (s *Server) rejectRequest(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	fields := logrus.Fields{
+		"uri":    r.RequestURI,
+		"method": r.Method,
+		"reason": msg,
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	s.logger(r.Context()).WithFields(fields).Warn("unauthenticated request")
+	s.writeError(w, r, problemUnauthenticated, msg)
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"packs-api/internal/auth"
+	"packs-api/internal/resources"
+	"packs-api/internal/store"
+	"packs-api/internal/utils"
+	"packs-api/mocks"
+)
+
+func TestServer_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	keyID := primitive.NewObjectID()
+	apiKey := &resources.APIKey{ID: keyID, Name: "warehouse", KeyHash: utils.HashAPIKey("secret"), Role: "operator"}
+
+	mongoDB := mocks.NewMockNoSQLStore(ctrl)
+	mongoDB.EXPECT().GetAPIKey(gomock.Any(), utils.HashAPIKey("secret")).Return(apiKey, nil).AnyTimes()
+	mongoDB.EXPECT().GetAPIKey(gomock.Any(), utils.HashAPIKey("unknown")).Return(nil, store.ErrNotFound).AnyTimes()
+	mongoDB.EXPECT().GetAPIKey(gomock.Any(), utils.HashAPIKey("broken")).Return(nil, errors.New("store error")).AnyTimes()
+
+	verifier := auth.NewHMACVerifier([]byte("jwt-secret"), "", "")
+	signToken := func(secret string, claims *auth.Claims) string {
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		return token
+	}
+	exp := jwt.NewNumericDate(time.Now().Add(time.Hour))
+	annToken := signToken("jwt-secret", &auth.Claims{
+		Name:             "Ann",
+		Roles:            []string{"viewer"},
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "ann", ExpiresAt: exp},
+	})
+	bobToken := signToken("jwt-secret", &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "bob", ExpiresAt: exp}})
+	forgedToken := signToken("other", &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "ann", ExpiresAt: exp}})
+
+	s := new(Server)
+	s.Log = utils.NewLogger("test", "packs-api")
+
+	tests := []struct {
+		name       string
+		path       string
+		key        string
+		token      string
+		status     int
+		errorCode  string
+		errorMsg   string
+		wantCaller *Caller
+	}{
+		{"status is exempt", "/api/status", "", "", 200, "", "", nil},
+		{"status subpath is exempt", "/api/status/ready", "", "", 200, "", "", nil},
+		{"missing credentials", "/api/orders", "", "", 401, "unauthenticated", "missing credentials", nil},
+		{"unknown key", "/api/orders", "unknown", "", 401, "unauthenticated", "invalid API key", nil},
+		{"store error", "/api/orders", "broken", "", 500, "internal_error", internalErrorDetail, nil},
+		{"valid key", "/api/orders", "secret", "", 200, "", "", &Caller{ID: keyID.Hex(), Name: "warehouse", Role: auth.RoleOperator}},
+		{"valid token", "/api/orders", "", annToken, 200, "", "", &Caller{ID: "ann", Name: "Ann", Role: auth.RoleViewer}},
+		{"token without name or roles", "/api/orders", "", bobToken, 200, "", "", &Caller{ID: "bob", Name: "bob", Role: auth.RoleNone}},
+		{"forged token", "/api/orders", "", forgedToken, 401, "unauthenticated", "invalid bearer token", nil},
+		{"token wins over key", "/api/orders", "secret", annToken, 200, "", "", &Caller{ID: "ann", Name: "Ann", Role: auth.RoleViewer}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var caller *Caller
+			next := func(w http.ResponseWriter, r *http.Request) {
+				caller = CallerFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}
+
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set("X-API-KEY", tt.key)
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			rr := httptest.NewRecorder()
+			router := mux.NewRouter()
+			router.Use(s.authenticate(mongoDB, verifier, "/api/status"))
+			router.HandleFunc("/api/status", next)
+			router.HandleFunc("/api/status/ready", next)
+			router.HandleFunc("/api/orders", next)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+
+			if tt.errorMsg != "" {
+				assertProblem(t, rr, tt.errorCode, tt.errorMsg)
+			}
+
+			assert.Equal(t, tt.wantCaller, caller)
+		})
+	}
+}
+
+func TestServer_RequireRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		authEnabled bool
+		caller      *Caller
+		status      int
+		errorCode   string
+		errorMsg    string
+	}{
+		{"auth disabled", false, nil, 200, "", ""},
+		{"no caller", true, nil, 401, "unauthenticated", "missing credentials"},
+		{"role too low", true, &Caller{ID: "ann", Role: auth.RoleViewer}, 403, "forbidden", "operator role required"},
+		{"no role", true, &Caller{ID: "bob"}, 403, "forbidden", "operator role required"},
+		{"same role", true, &Caller{ID: "ann", Role: auth.RoleOperator}, 200, "", ""},
+		{"higher role", true, &Caller{ID: "ann", Role: auth.RoleAdmin}, 200, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(Server)
+			s.Log = utils.NewLogger("test", "packs-api")
+			s.authEnabled = tt.authEnabled
+
+			ctx := context.Background()
+			if tt.caller != nil {
+				ctx = withCaller(ctx, tt.caller)
+			}
+			req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/api/orders", nil)
+
+			rr := httptest.NewRecorder()
+			s.requireRole(auth.RoleOperator, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.errorMsg != "" {
+				assertProblem(t, rr, tt.errorCode, tt.errorMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+var (
+	orderRequestSchema      = mustCompileSchema("order_request.json")
+	orderPatchRequestSchema = mustCompileSchema("order_patch_request.json")
+)
+
+// schemaErrorCodes maps JSON Schema keywords to field error codes. Other
+// keywords are reported as invalid.
+var schemaErrorCodes = map[string]string{
+	"type":                 "invalid_type",
+	"required":             "required",
+	"additionalProperties": "unknown_field",
+	"pattern":              "invalid_key",
+}
+
+func mustCompileSchema(name string) *jsonschema.Schema {
+	b, err := schemaFiles.ReadFile("schemas/" + name)
+	if err != nil {
+		panic(err)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	if err := c.AddResource(name, bytes.NewReader(b)); err != nil {
+		panic(err)
+	}
+	return c.MustCompile(name)
+}
+
+// limitBody makes reading more than MaxBodyBytes of the request body fail. A
+// zero MaxBodyBytes leaves the body unlimited.
+func (s *Server) limitBody(w http.ResponseWriter, r *http.Request) {
+	if s.MaxBodyBytes > 0 && r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, s.MaxBodyBytes)
+	}
+}
+
+// writeBodyReadError writes the response for a request body that could not
+// be read.
+func (s *Server) writeBodyReadError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		s.writeError(w, r, problemBodyTooLarge, fmt.Sprintf("request body must be at most %d bytes", maxErr.Limit))
+		return
+	}
+	s.writeError(w, r, problemMalformedBody, "request body could not be read")
+}
+
+// decodeJSONBody reads the JSON body into v, rejecting fields v does not have.
+// The body is first validated against schema unless it is nil. On failure it
+// writes the error response, listing every schema violation, and returns
+// false.
+func (s *Server) decodeJSONBody(w http.ResponseWriter, r *http.Request, schema *jsonschema.Schema, v interface{}) bool {
+	if !s.HasContentType(r, "application/json") {
+		s.writeError(w, r, problemUnsupportedMediaType, "Content-Type must be application/json")
+		return false
+	}
+
+	var b []byte
+	if r.Body != nil {
+		s.limitBody(w, r)
+		var err error
+		b, err = io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			s.writeBodyReadError(w, r, err)
+			return false
+		}
+	}
+	if len(b) == 0 {
+		s.writeError(w, r, problemMalformedBody, "request body is empty")
+		return false
+	}
+
+	var doc interface{}
+	err := json.Unmarshal(b, &doc)
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		s.writeError(w, r, problemMalformedBody, fmt.Sprintf("invalid JSON at offset %d", syntaxErr.Offset))
+		return false
+	case err != nil:
+		s.writeError(w, r, problemMalformedBody, "invalid JSON")
+		return false
+	}
+
+	if schema != nil {
+		var ve *jsonschema.ValidationError
+		err := schema.Validate(doc)
+		if errors.As(err, &ve) {
+			writeFieldErrors(w, r, schemaFieldErrors(ve))
+			return false
+		}
+		if err != nil {
+			s.writeInternalError(w, r, fmt.Errorf("error validating request body: %w", err), "failed to validate request body")
+			return false
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(v)
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		writeFieldErrors(w, r, []FieldError{{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: fmt.Sprintf("must be %s", jsonTypeName(typeErr.Type)),
+		}})
+		return false
+	case err != nil && strings.HasPrefix(err.Error(), "json: unknown field "):
+		// The decoder has no error type for unknown fields
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		writeFieldErrors(w, r, []FieldError{{Field: field, Code: "unknown_field", Message: "is not a known field"}})
+		return false
+	case err != nil:
+		s.writeError(w, r, problemMalformedBody, "invalid JSON")
+		return false
+	}
+
+	return true
+}
+
+// writeFieldErrors writes a validation problem listing errs, with a detail
+// made of the field names and messages.
+func writeFieldErrors(w http.ResponseWriter, r *http.Request, errs []FieldError) {
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		if e.Field == "" {
+			msgs = append(msgs, e.Message)
+		} else {
+			msgs = append(msgs, e.Field+": "+e.Message)
+		}
+	}
+
+	p := newProblem(problemValidationFailed, strings.Join(msgs, "; "))
+	p.Errors = errs
+	writeProblem(w, r, p)
+}
+
+// schemaFieldErrors flattens the schema violations of ve into field errors,
+// sorted by field and code since the validator finds them in no fixed order.
+func schemaFieldErrors(ve *jsonschema.ValidationError) []FieldError {
+	errs := schemaViolations(ve)
+	sort.Slice(errs, func(i, j int) bool {
+		if errs[i].Field != errs[j].Field {
+			return errs[i].Field < errs[j].Field
+		}
+		return errs[i].Code < errs[j].Code
+	})
+	return errs
+}
+
+func schemaViolations(ve *jsonschema.ValidationError) []FieldError {
+	if len(ve.Causes) == 0 {
+		code, ok := schemaErrorCodes[path.Base(ve.KeywordLocation)]
+		if !ok {
+			code = "invalid"
+		}
+		return []FieldError{{Field: jsonPointerField(ve.InstanceLocation), Code: code, Message: ve.Message}}
+	}
+
+	var errs []FieldError
+	for _, cause := range ve.Causes {
+		errs = append(errs, schemaViolations(cause)...)
+	}
+	return errs
+}
+
+// jsonPointerField turns a JSON pointer like /packSizes/0 into the field name
+// packSizes.0.
+func jsonPointerField(ptr string) string {
+	parts := strings.Split(strings.TrimPrefix(ptr, "/"), "/")
+	for i, p := range parts {
+		parts[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// jsonTypeName describes the JSON value that decodes into t.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	default:
+		return "a valid value"
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"packs-api/internal/resources"
+	"packs-api/internal/utils"
+)
+
+func TestServer_DecodeJSONBody(t *testing.T) {
+	s := new(Server)
+	s.Log = utils.NewLogger("test", "packs-api")
+
+	tests := []struct {
+		name        string
+		body        string
+		noSchema    bool
+		errorMsg    string
+		fieldErrors []interface{}
+	}{
+		{
+			"valid", `{"items": 10, "packSizes": [1, 2], "availability": {"2": 3}}`, false, "", nil,
+		},
+		{
+			"every violation", `{"items": 1.5, "packSizes": ["a", 2], "availability": {"big": 1}, "colour": "red"}`, false,
+			"additionalProperties 'colour' not allowed; availability.big: does not match pattern '^[0-9]+$'; items: expected integer, but got number; packSizes.0: expected integer, but got string",
+			[]interface{}{
+				map[string]interface{}{"field": "", "code": "unknown_field", "message": "additionalProperties 'colour' not allowed"},
+				map[string]interface{}{"field": "availability.big", "code": "invalid_key", "message": "does not match pattern '^[0-9]+$'"},
+				map[string]interface{}{"field": "items", "code": "invalid_type", "message": "expected integer, but got number"},
+				map[string]interface{}{"field": "packSizes.0", "code": "invalid_type", "message": "expected integer, but got string"},
+			},
+		},
+		{
+			"unknown field without schema", `{"items": 10, "packSizes": [1], "colour": "red"}`, true,
+			"colour: is not a known field",
+			[]interface{}{
+				map[string]interface{}{"field": "colour", "code": "unknown_field", "message": "is not a known field"},
+			},
+		},
+		{
+			"wrong type without schema", `{"items": "10", "packSizes": [1]}`, true,
+			"items: must be an integer",
+			[]interface{}{
+				map[string]interface{}{"field": "items", "code": "invalid_type", "message": "must be an integer"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/api/orders", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+
+			schema := orderRequestSchema
+			if tt.noSchema {
+				schema = nil
+			}
+
+			rr := httptest.NewRecorder()
+			var orderRequest resources.OrderRequest
+			ok := s.decodeJSONBody(rr, req, schema, &orderRequest)
+
+			if tt.errorMsg == "" {
+				assert.True(t, ok)
+				assert.Equal(t, resources.OrderRequest{Items: 10, PackSizes: []int{1, 2}, Availability: map[int]int{2: 3}}, orderRequest)
+				return
+			}
+
+			assert.False(t, ok)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			res := assertProblem(t, rr, "validation_failed", tt.errorMsg)
+			assert.Equal(t, tt.fieldErrors, res["errors"])
+		})
+	}
+}
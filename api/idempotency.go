@@ -0,0 +1,229 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"packs-api/internal/resources"
+	"packs-api/internal/store"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotentReplayHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength = 255
+	// idempotencyLease is how long an in-progress record holds its key, so
+	// that a request cut short by a crash only blocks retries that long.
+	idempotencyLease = time.Minute
+)
+
+// replayedHeaders are the response headers kept for replays.
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// idempotent makes next safe to retry with an Idempotency-Key header. The
+// first request with a key holds it for idempotencyLease while next runs and,
+// if it succeeds, its response is stored for IdempotencyTTL. Retries with the
+// same method, route and body get the stored response, while other requests
+// reusing the key get a 422.
+// Failed responses are not stored, so the request can be retried as is.
+func (s *Server) idempotent(mongoDB store.NoSQLStore, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			s.writeError(w, r, problemInvalidHeader, fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength))
+			return
+		}
+
+		var body []byte
+		if r.Body != nil {
+			s.limitBody(w, r)
+			b, err := io.ReadAll(r.Body)
+			_ = r.Body.Close()
+			if err != nil {
+				s.writeBodyReadError(w, r, err)
+				return
+			}
+			body = b
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		// Keys are scoped to the caller, so clients cannot see each other's
+		// responses
+		if caller := CallerFromContext(ctx); caller != nil {
+			key = caller.ID + ":" + key
+		}
+
+		now := s.Time.Now()
+		record := &resources.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: requestFingerprint(r, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(idempotencyLease),
+		}
+
+		existing, ok := s.reserveIdempotencyKey(w, r, mongoDB, record)
+		if !ok {
+			return
+		}
+		if existing != nil {
+			s.replayResponse(w, r, record, existing)
+			return
+		}
+
+		// The key is released unless next succeeds, also when it panics
+		release := true
+		defer func() {
+			if !release {
+				return
+			}
+			err := mongoDB.DeleteIdempotencyRecord(context.WithoutCancel(ctx), key)
+			if err != nil {
+				s.logger(r.Context()).WithField("error", err.Error()).Error("failed to release idempotency key")
+			}
+		}()
+
+		cw := newCaptureResponseWriter(w)
+		next(cw, r)
+
+		if cw.statusCode < 200 || cw.statusCode >= 300 {
+			return
+		}
+		release = false
+
+		record.StatusCode = cw.statusCode
+		record.ExpiresAt = s.Time.Now().Add(s.IdempotencyTTL)
+		record.Body = cw.body.Bytes()
+		record.Header = make(map[string]string)
+		for _, h := range replayedHeaders {
+			if v := cw.Header().Get(h); v != "" {
+				record.Header[h] = v
+			}
+		}
+
+		err := mongoDB.UpdateIdempotencyRecord(context.WithoutCancel(ctx), record)
+		if err != nil {
+			s.logger(r.Context()).WithField("error", err.Error()).Error("failed to store idempotent response")
+		}
+	}
+}
+
+// reserveIdempotencyKey stores the in-progress record. If the key is taken
+// by a live record, that record is returned instead. Expired records are
+// replaced. On failure it writes the error response and returns false.
+func (s *Server) reserveIdempotencyKey(w http.ResponseWriter, r *http.Request, mongoDB store.NoSQLStore, record *resources.IdempotencyRecord) (*resources.IdempotencyRecord, bool) {
+	ctx := r.Context()
+
+	// Each retry follows a concurrent release of the key, so a few suffice
+	for attempt := 0; attempt < 3; attempt++ {
+		err := mongoDB.CreateIdempotencyRecord(ctx, record)
+		if err == nil {
+			return nil, true
+		}
+		if !errors.Is(err, store.ErrDuplicate) {
+			s.writeInternalError(w, r, fmt.Errorf("error storing idempotency key: %w", err), "failed to store idempotency key")
+			return nil, false
+		}
+
+		existing, err := mongoDB.GetIdempotencyRecord(ctx, record.Key)
+		if errors.Is(err, store.ErrNotFound) {
+			// Released or expired in the meantime
+			continue
+		}
+		if err != nil {
+			s.writeInternalError(w, r, fmt.Errorf("error getting idempotency key: %w", err), "failed to get idempotency key")
+			return nil, false
+		}
+
+		if existing.ExpiresAt.After(record.CreatedAt) {
+			return existing, true
+		}
+
+		err = mongoDB.DeleteIdempotencyRecord(ctx, record.Key)
+		if err != nil {
+			s.writeInternalError(w, r, fmt.Errorf("error deleting idempotency key: %w", err), "failed to delete idempotency key")
+			return nil, false
+		}
+	}
+
+	s.writeError(w, r, problemIdempotencyKeyInProgress, fmt.Sprintf("a request with this %s is still in progress", idempotencyKeyHeader))
+	return nil, false
+}
+
+// replayResponse writes the stored response of existing if it was made for
+// the same request as record.
+func (s *Server) replayResponse(w http.ResponseWriter, r *http.Request, record, existing *resources.IdempotencyRecord) {
+	if existing.Fingerprint != record.Fingerprint {
+		s.writeError(w, r, problemIdempotencyKeyReused, fmt.Sprintf("%s was already used for a different request", idempotencyKeyHeader))
+		return
+	}
+	if existing.StatusCode == 0 {
+		s.writeError(w, r, problemIdempotencyKeyInProgress, fmt.Sprintf("a request with this %s is still in progress", idempotencyKeyHeader))
+		return
+	}
+
+	for h, v := range existing.Header {
+		w.Header().Set(h, v)
+	}
+	w.Header().Set(idempotentReplayHeader, strconv.FormatBool(true))
+	w.WriteHeader(existing.StatusCode)
+	_, _ = w.Write(existing.Body)
+}
+
+// requestFingerprint hashes what makes two requests the same. Requests to the
+// same route of the same API version match whether or not their path names
+// the version, e.g. /api/orders and /api/v1/orders.
+func requestFingerprint(r *http.Request, body []byte) string {
+	route := r.URL.Path
+	if current := mux.CurrentRoute(r); current != nil {
+		if tpl, err := current.GetPathTemplate(); err == nil {
+			route = tpl
+		}
+	}
+	version := APIVersionFromContext(r.Context())
+	if version != "" {
+		route = strings.Replace(route, "/"+version+"/", "/", 1)
+	}
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s %s %s\n", r.Method, version, route)
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// captureResponseWriter passes the response through while keeping a copy.
+type captureResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func newCaptureResponseWriter(w http.ResponseWriter) *captureResponseWriter {
+	return &captureResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+}
+
+func (cw *captureResponseWriter) WriteHeader(code int) {
+	cw.statusCode = code
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *captureResponseWriter) Write(b []byte) (int, error) {
+	cw.body.Write(b)
+	return cw.ResponseWriter.Write(b)
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"packs-api/internal/config"
+	"packs-api/internal/resources"
+	"packs-api/internal/store"
+	"packs-api/internal/utils"
+	"packs-api/mocks"
+)
+
+// expectIdempotencyRecords backs the idempotency methods of the mock store
+// with records.
+func expectIdempotencyRecords(mongoDB *mocks.MockNoSQLStore, records map[string]resources.IdempotencyRecord) {
+	mongoDB.EXPECT().CreateIdempotencyRecord(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, record *resources.IdempotencyRecord) error {
+			if _, ok := records[record.Key]; ok {
+				return store.ErrDuplicate
+			}
+			records[record.Key] = *record
+			return nil
+		}).AnyTimes()
+	mongoDB.EXPECT().GetIdempotencyRecord(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string) (*resources.IdempotencyRecord, error) {
+			record, ok := records[key]
+			if !ok {
+				return nil, store.ErrNotFound
+			}
+			return &record, nil
+		}).AnyTimes()
+	mongoDB.EXPECT().UpdateIdempotencyRecord(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, record *resources.IdempotencyRecord) error {
+			records[record.Key] = *record
+			return nil
+		}).AnyTimes()
+	mongoDB.EXPECT().DeleteIdempotencyRecord(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string) error {
+			delete(records, key)
+			return nil
+		}).AnyTimes()
+}
+
+func TestServer_Idempotent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	now := time.Date(2023, 11, 04, 20, 34, 58, 651387237, time.UTC)
+	freezedTime := mocks.NewMockTime(ctrl)
+	freezedTime.EXPECT().Now().DoAndReturn(func() time.Time { return now }).AnyTimes()
+
+	records := make(map[string]resources.IdempotencyRecord)
+	mongoDB := mocks.NewMockNoSQLStore(ctrl)
+	expectIdempotencyRecords(mongoDB, records)
+
+	s := new(Server)
+	s.Time = freezedTime
+	s.Log = utils.NewLogger("test", "packs-api")
+	s.IdempotencyTTL = time.Hour
+
+	// next creates an order per call, failing for bodies asking for it
+	calls := 0
+	next := s.idempotent(mongoDB, func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Fail bool }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Fail {
+			s.writeError(w, r, problemValidationFailed, "bad order")
+			return
+		}
+		calls++
+		w.Header().Set("Location", "/api/orders/"+strconv.Itoa(calls))
+		s.writeJSONData(w, http.StatusCreated, map[string]int{"order": calls})
+	})
+
+	tests := []struct {
+		name      string
+		key       string
+		caller    *Caller
+		body      string
+		elapsed   time.Duration
+		status    int
+		wantBody  string
+		errorCode string
+		wantCalls int
+		replayed  bool
+	}{
+		{"no key", "", nil, `{}`, 0, 201, `{"data":{"order":1}}`, "", 1, false},
+		{"first with key", "k1", nil, `{}`, 0, 201, `{"data":{"order":2}}`, "", 2, false},
+		{"replay", "k1", nil, `{}`, 0, 201, `{"data":{"order":2}}`, "", 2, true},
+		{"different body", "k1", nil, `{"items": 1}`, 0, 422, "Idempotency-Key was already used for a different request", "idempotency_key_reused", 2, false},
+		{"same key of another caller", "k1", &Caller{ID: "ann"}, `{}`, 0, 201, `{"data":{"order":3}}`, "", 3, false},
+		{"failure is not stored", "k2", nil, `{"fail": true}`, 0, 400, "bad order", "validation_failed", 3, false},
+		{"retry after failure", "k2", nil, `{}`, 0, 201, `{"data":{"order":4}}`, "", 4, false},
+		{"expired key", "k1", nil, `{"items": 1}`, 2 * time.Hour, 201, `{"data":{"order":5}}`, "", 5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.elapsed)
+
+			ctx := context.Background()
+			if tt.caller != nil {
+				ctx = withCaller(ctx, tt.caller)
+			}
+			req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/api/orders", bytes.NewReader([]byte(tt.body)))
+			if tt.key != "" {
+				req.Header.Set("Idempotency-Key", tt.key)
+			}
+
+			rr := httptest.NewRecorder()
+			next(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.errorCode != "" {
+				assertProblem(t, rr, tt.errorCode, tt.wantBody)
+			} else {
+				assert.JSONEq(t, tt.wantBody, rr.Body.String())
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+			if tt.replayed {
+				assert.Equal(t, "true", rr.Header().Get("Idempotent-Replayed"))
+				assert.Equal(t, "/api/orders/2", rr.Header().Get("Location"))
+				assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+			} else {
+				assert.Empty(t, rr.Header().Get("Idempotent-Replayed"))
+			}
+		})
+	}
+}
+
+func TestServer_Idempotent_InProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	now := time.Date(2023, 11, 04, 20, 34, 58, 651387237, time.UTC)
+	freezedTime := mocks.NewMockTime(ctrl)
+	freezedTime.EXPECT().Now().Return(now).AnyTimes()
+
+	records := make(map[string]resources.IdempotencyRecord)
+	mongoDB := mocks.NewMockNoSQLStore(ctrl)
+	expectIdempotencyRecords(mongoDB, records)
+
+	s := new(Server)
+	s.Time = freezedTime
+	s.Log = utils.NewLogger("test", "packs-api")
+	s.IdempotencyTTL = time.Hour
+
+	// The inner handler sends the same request again while the first runs
+	var inner *httptest.ResponseRecorder
+	var handler http.HandlerFunc
+	handler = s.idempotent(mongoDB, func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequest(http.MethodPost, "/api/orders", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set("Idempotency-Key", "k1")
+		inner = httptest.NewRecorder()
+		handler(inner, req)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/orders", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set("Idempotency-Key", "k1")
+	handler(httptest.NewRecorder(), req)
+
+	assert.Equal(t, http.StatusConflict, inner.Code)
+	assertProblem(t, inner, "idempotency_key_in_progress", "a request with this Idempotency-Key is still in progress")
+}
+
+func TestServer_Idempotent_Lease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	now := time.Date(2023, 11, 04, 20, 34, 58, 651387237, time.UTC)
+	freezedTime := mocks.NewMockTime(ctrl)
+	freezedTime.EXPECT().Now().DoAndReturn(func() time.Time { return now }).AnyTimes()
+
+	records := make(map[string]resources.IdempotencyRecord)
+	mongoDB := mocks.NewMockNoSQLStore(ctrl)
+	expectIdempotencyRecords(mongoDB, records)
+
+	s := new(Server)
+	s.Time = freezedTime
+	s.Log = utils.NewLogger("test", "packs-api")
+	s.IdempotencyTTL = time.Hour
+
+	// The in-progress record only holds the key for the lease, and the
+	// stored response for IdempotencyTTL from when it is stored
+	next := s.idempotent(mongoDB, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, now.Add(idempotencyLease), records["k1"].ExpiresAt)
+		now = now.Add(time.Second)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/orders", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set("Idempotency-Key", "k1")
+	next(httptest.NewRecorder(), req)
+
+	assert.Equal(t, now.Add(time.Hour), records["k1"].ExpiresAt)
+}
+
+func TestNewServer_IdempotentVersionAliases(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	records := make(map[string]resources.IdempotencyRecord)
+	mongoDB := mocks.NewMockNoSQLStore(ctrl)
+	expectIdempotencyRecords(mongoDB, records)
+	mongoDB.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+	cfg := &config.Config{
+		PathPrefix:     "/api",
+		APIVersions:    []config.APIVersion{{Name: "v1"}, {Name: "v2"}},
+		MongoDB:        mongoDB,
+		IdempotencyTTL: time.Hour,
+	}
+	s := NewServer(cfg, utils.NewLogger("test", "packs-api"))
+
+	tests := []struct {
+		path     string
+		status   int
+		replayed bool
+	}{
+		{"/api/orders", 201, false},
+		{"/api/v1/orders", 201, true},
+		// Versions respond differently, so the key cannot be reused across them
+		{"/api/v2/orders", 422, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			body := `{"items": 501, "packSizes": [250, 500]}`
+			req, _ := http.NewRequest(http.MethodPost, tt.path, bytes.NewReader([]byte(body)))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Idempotency-Key", "k1")
+			rr := httptest.NewRecorder()
+			s.srv.Handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.replayed {
+				assert.Equal(t, "true", rr.Header().Get("Idempotent-Replayed"))
+			} else {
+				assert.Empty(t, rr.Header().Get("Idempotent-Replayed"))
+			}
+		})
+	}
+}
+
+func TestServer_Idempotent_StoreError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	freezedTime := mocks.NewMockTime(ctrl)
+	freezedTime.EXPECT().Now().Return(time.Date(2023, 11, 04, 20, 34, 58, 651387237, time.UTC)).AnyTimes()
+
+	mongoDB := mocks.NewMockNoSQLStore(ctrl)
+	mongoDB.EXPECT().CreateIdempotencyRecord(gomock.Any(), gomock.Any()).Return(errors.New("store error")).Times(1)
+
+	s := new(Server)
+	s.Time = freezedTime
+	s.Log = utils.NewLogger("test", "packs-api")
+
+	next := s.idempotent(mongoDB, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler called despite the store error")
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/orders", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set("Idempotency-Key", "k1")
+	rr := httptest.NewRecorder()
+	next(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assertProblem(t, rr, "internal_error", internalErrorDetail)
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"packs-api/internal/metrics"
+)
+
+// measureRequests records every request in metrics.HTTPRequests and
+// metrics.HTTPRequestDuration. Requests are labelled with the template of the
+// route they matched, e.g. /api/orders/{id}, so order IDs do not each make a
+// new series.
+func (s *Server) measureRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := s.Time.Now()
+		lrw := newLogResponseWriter(w, nil)
+		next.ServeHTTP(lrw, r)
+		duration := s.Time.Now().Sub(start)
+
+		route := routeTemplate(r)
+		status := lrw.statusCode
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		labels := []string{route, r.Method, strconv.Itoa(status)}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(duration.Seconds())
+	})
+}
+
+// routeTemplate returns the template of the route r matched, or an empty
+// string outside of the router.
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	tpl, _ := route.GetPathTemplate()
+	return tpl
+}
+
+// serveMetrics serves the metrics on path and passes every other request to
+// next. The metrics are outside the router, so scraping them is not
+// authenticated, rate limited or logged.
+func serveMetrics(path string, next http.Handler) http.Handler {
+	h := metrics.Handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == path && r.Method == http.MethodGet {
+			h.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
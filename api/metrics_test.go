@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"packs-api/internal/metrics"
+	"packs-api/internal/utils"
+)
+
+func TestServer_MeasureRequests(t *testing.T) {
+	s := new(Server)
+	s.Log = utils.NewLogger("test", "packs-api")
+	s.Time = utils.NewRealTime()
+
+	router := mux.NewRouter()
+	router.Use(s.measureRequests)
+	router.HandleFunc("/api/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "missing" {
+			s.writeError(w, r, problemOrderNotFound, "order not found")
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	}).Methods(http.MethodGet)
+
+	tests := []struct {
+		path   string
+		status string
+	}{
+		{"/api/orders/1", "200"},
+		{"/api/orders/2", "200"},
+		{"/api/orders/missing", "404"},
+	}
+
+	before := map[string]float64{}
+	for _, tt := range tests {
+		before[tt.status] = testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("/api/orders/{id}", http.MethodGet, tt.status))
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Requests for different orders share the series of their route
+	assert.Equal(t, before["200"]+2, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("/api/orders/{id}", http.MethodGet, "200")))
+	assert.Equal(t, before["404"]+1, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("/api/orders/{id}", http.MethodGet, "404")))
+}
+
+func TestServeMetrics(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := serveMetrics("/metrics", next)
+
+	metrics.StoreErrors.WithLabelValues("get_order").Inc()
+
+	req, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, strings.Contains(rr.Body.String(), `packs_store_errors_total{operation="get_order"}`))
+
+	req, _ = http.NewRequest(http.MethodGet, "/api/metrics", nil)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+}
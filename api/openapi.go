@@ -0,0 +1,471 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"packs-api/internal/auth"
+	"packs-api/internal/resources"
+)
+
+const openAPIVersion = "3.1.0"
+
+// openAPIOperation documents a route registered in NewServer. Its path is
+// relative to the prefix of the API version, or to the path prefix for
+// unversioned routes.
+type openAPIOperation struct {
+	method      string
+	path        string
+	unversioned bool
+	summary     string
+	// role is the least role that may call the route, RoleNone when any
+	// caller may.
+	role   auth.Role
+	public bool
+	params []openAPIParam
+	// request names the component schema of the request body.
+	request string
+	status  int
+	// response is the value written in the {"data": ...} envelope, or the
+	// whole body when raw is set. Nil for no body.
+	response interface{}
+	// versionResponses replace response in the API versions named by
+	// their keys.
+	versionResponses map[string]interface{}
+	raw              bool
+	// failureStatus is the status of failed responses with the same body,
+	// zero if there are none.
+	failureStatus int
+	errors        []problemKind
+}
+
+type openAPIParam struct {
+	name        string
+	in          string
+	description string
+	schema      map[string]interface{}
+}
+
+// orderPage is the body of GET /orders.
+type orderPage struct {
+	Data   []resources.Order `json:"data"`
+	Paging Paging            `json:"paging"`
+}
+
+// orderPageV2 is the body of GET /api/v2/orders.
+type orderPageV2 struct {
+	Data   []resources.OrderV2 `json:"data"`
+	Paging Paging              `json:"paging"`
+}
+
+var orderV2Responses = map[string]interface{}{apiVersionV2: resources.OrderV2{}}
+
+var requestIDSchema = map[string]interface{}{"type": "string", "pattern": "^[!-~]{1,128}$"}
+
+var orderIDParam = openAPIParam{"id", "path", "The order ID.", map[string]interface{}{"type": "string", "pattern": "^[0-9a-f]{24}$"}}
+
+var bodyErrors = []problemKind{problemUnsupportedMediaType, problemMalformedBody, problemBodyTooLarge, problemValidationFailed}
+
+var openAPIOperations = []openAPIOperation{
+	{
+		method: http.MethodGet, path: "/status", unversioned: true, summary: "Check that the service is up",
+		public: true, status: http.StatusOK, response: statusResponse{}, raw: true,
+	},
+	{
+		method: http.MethodGet, path: "/status/live", unversioned: true, summary: "Check that the process is up",
+		public: true, status: http.StatusOK, response: statusResponse{}, raw: true,
+	},
+	{
+		method: http.MethodGet, path: "/status/ready", unversioned: true, summary: "Check that the service and its dependencies can serve requests",
+		public: true, status: http.StatusOK, response: readinessResponse{}, raw: true,
+		failureStatus: http.StatusServiceUnavailable,
+	},
+	{
+		method: http.MethodGet, path: "/openapi.json", summary: "Get this document",
+		status: http.StatusOK, response: map[string]interface{}{}, raw: true,
+	},
+	{
+		method: http.MethodPost, path: "/orders", summary: "Pack and store an order",
+		role: auth.RoleOperator, request: "OrderRequest",
+		params: []openAPIParam{
+			{idempotencyKeyHeader, "header", "Makes the request safe to retry. Up to 255 characters.", map[string]interface{}{"type": "string", "maxLength": maxIdempotencyKeyLength}},
+		},
+		status: http.StatusCreated, response: resources.Order{}, versionResponses: orderV2Responses,
+		errors: append(append([]problemKind{}, bodyErrors...),
+			problemNoSolution, problemInsufficientStock, problemOverageNotAllowed, problemOrderTooLarge,
+			problemInvalidHeader, problemIdempotencyKeyReused, problemIdempotencyKeyInProgress),
+	},
+	{
+		method: http.MethodGet, path: "/orders", summary: "List orders",
+		role: auth.RoleViewer,
+		params: []openAPIParam{
+			{"limit", "query", fmt.Sprintf("Orders per page, %d by default.", defaultOrdersLimit), map[string]interface{}{"type": "integer", "minimum": 1, "maximum": maxOrdersLimit}},
+			{"after", "query", "The nextCursor of the previous page.", map[string]interface{}{"type": "string"}},
+			{"createdFrom", "query", "Only orders created at or after this time.", map[string]interface{}{"type": "string", "format": "date-time"}},
+			{"createdTo", "query", "Only orders created before this time.", map[string]interface{}{"type": "string", "format": "date-time"}},
+			{"minItems", "query", "Only orders of at least this many items.", map[string]interface{}{"type": "integer"}},
+			{"maxItems", "query", "Only orders of at most this many items.", map[string]interface{}{"type": "integer"}},
+			{"packSize", "query", "Only orders offering this pack size.", map[string]interface{}{"type": "integer"}},
+			{"sort", "query", "Sort field, prefixed with - for descending order.", map[string]interface{}{"type": "string", "enum": []string{"createdAt", "-createdAt", "items", "-items"}}},
+		},
+		status: http.StatusOK, response: orderPage{}, raw: true,
+		versionResponses: map[string]interface{}{apiVersionV2: orderPageV2{}},
+		errors:           []problemKind{problemInvalidQuery},
+	},
+	{
+		method: http.MethodGet, path: "/orders/{id}", summary: "Get an order",
+		role: auth.RoleViewer, params: []openAPIParam{orderIDParam},
+		status: http.StatusOK, response: resources.Order{}, versionResponses: orderV2Responses,
+		errors: []problemKind{problemInvalidOrderID, problemOrderNotFound},
+	},
+	{
+		method: http.MethodPatch, path: "/orders/{id}", summary: "Change the items, pack sizes, stock or costs of an order and pack it again",
+		role: auth.RoleOperator, params: []openAPIParam{orderIDParam}, request: "OrderPatchRequest",
+		status: http.StatusOK, response: resources.Order{}, versionResponses: orderV2Responses,
+		errors: append(append([]problemKind{}, bodyErrors...),
+			problemInvalidOrderID, problemOrderNotFound, problemNoSolution, problemOverageNotAllowed, problemOrderTooLarge),
+	},
+	{
+		method: http.MethodDelete, path: "/orders/{id}", summary: "Delete an order",
+		role: auth.RoleAdmin, params: []openAPIParam{orderIDParam},
+		status: http.StatusNoContent,
+		errors: []problemKind{problemInvalidOrderID, problemOrderNotFound},
+	},
+	{
+		method: http.MethodPost, path: "/quotes", summary: "Pack an order without storing it",
+		role: auth.RoleViewer, request: "OrderRequest",
+		params: []openAPIParam{
+			{"alternatives", "query", "Also return this many of the best packings.", map[string]interface{}{"type": "integer", "minimum": 1, "maximum": maxAlternatives}},
+		},
+		status: http.StatusOK, response: resources.Quote{},
+		versionResponses: map[string]interface{}{apiVersionV2: resources.QuoteV2{}},
+		errors: append(append([]problemKind{problemInvalidQuery}, bodyErrors...),
+			problemNoSolution, problemInsufficientStock, problemOverageNotAllowed, problemOrderTooLarge),
+	},
+}
+
+// openAPIDocument describes the API version served below versionPrefix as an
+// OpenAPI 3.1 document. Unversioned routes are below pathPrefix. Request
+// bodies use the schemas decodeJSONBody validates against, and responses are
+// described from their Go types in the given version.
+func openAPIDocument(pathPrefix, versionPrefix, version string) (map[string]interface{}, error) {
+	schemas := make(map[string]interface{})
+	for name, file := range map[string]string{"OrderRequest": "order_request.json", "OrderPatchRequest": "order_patch_request.json"} {
+		b, err := schemaFiles.ReadFile("schemas/" + file)
+		if err != nil {
+			return nil, err
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(b, &schema); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", file, err)
+		}
+		delete(schema, "$schema")
+		delete(schema, "$id")
+		schemas[name] = schema
+	}
+	problemSchema := typeSchema(reflect.TypeOf(Problem{}), schemas)
+
+	paths := make(map[string]interface{})
+	for _, op := range openAPIOperations {
+		item, ok := paths[op.path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			if op.unversioned && pathPrefix != versionPrefix {
+				item["servers"] = []interface{}{map[string]interface{}{"url": pathPrefix}}
+			}
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = op.document(schemas, problemSchema, version)
+	}
+
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   "Packs API",
+			"version": "1.0.0",
+			"description": "Packs orders into the fewest extra items and packs. Errors are RFC 7807 problem details " +
+				"with a stable code.",
+		},
+		"servers": []interface{}{map[string]interface{}{"url": versionPrefix}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"parameters": map[string]interface{}{
+				"RequestID": map[string]interface{}{
+					"name":        requestIDHeader,
+					"in":          "header",
+					"description": "Identifies the request in the logs and error responses. A new ID is made when it is missing or invalid.",
+					"schema":      requestIDSchema,
+				},
+			},
+			"headers": map[string]interface{}{
+				"RequestID": map[string]interface{}{
+					"description": "The ID of the request, as sent or made by the server.",
+					"schema":      requestIDSchema,
+				},
+			},
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKeyAuth": map[string]interface{}{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+			},
+		},
+	}, nil
+}
+
+func (op openAPIOperation) document(schemas map[string]interface{}, problemSchema map[string]interface{}, version string) map[string]interface{} {
+	doc := map[string]interface{}{
+		"summary":     op.summary,
+		"operationId": operationID(op.method, op.path),
+	}
+
+	if op.public {
+		doc["security"] = []interface{}{}
+	} else {
+		doc["security"] = []interface{}{
+			map[string]interface{}{"bearerAuth": []interface{}{}},
+			map[string]interface{}{"apiKeyAuth": []interface{}{}},
+		}
+	}
+	if op.role != auth.RoleNone {
+		doc["x-required-role"] = op.role.String()
+	}
+
+	// Every route takes and returns a request ID, see assignRequestID
+	params := []interface{}{map[string]interface{}{"$ref": "#/components/parameters/RequestID"}}
+	for _, p := range op.params {
+		params = append(params, map[string]interface{}{
+			"name":        p.name,
+			"in":          p.in,
+			"description": p.description,
+			"required":    p.in == "path",
+			"schema":      p.schema,
+		})
+	}
+	doc["parameters"] = params
+
+	if op.request != "" {
+		doc["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": componentRef(op.request)},
+			},
+		}
+	}
+
+	response := op.response
+	if r, ok := op.versionResponses[version]; ok {
+		response = r
+	}
+	success := map[string]interface{}{"description": http.StatusText(op.status)}
+	if response != nil {
+		schema := typeSchema(reflect.TypeOf(response), schemas)
+		if !op.raw {
+			schema = map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"data": schema},
+				"required":   []string{"data"},
+			}
+		}
+		success["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		}
+	}
+	responses := map[string]interface{}{fmt.Sprint(op.status): success}
+	if op.failureStatus != 0 {
+		responses[fmt.Sprint(op.failureStatus)] = map[string]interface{}{
+			"description": http.StatusText(op.failureStatus),
+			"content":     success["content"],
+		}
+	}
+
+	// Every route can be rate limited or fail, and the others can reject
+	// the caller
+	kinds := append([]problemKind{}, op.errors...)
+	if !op.public {
+		kinds = append(kinds, problemUnauthenticated, problemRateLimited)
+	}
+	if op.role != auth.RoleNone {
+		kinds = append(kinds, problemForbidden)
+	}
+	kinds = append(kinds, problemInternal)
+
+	codes := make(map[int][]string)
+	for _, kind := range kinds {
+		codes[kind.status] = append(codes[kind.status], kind.code)
+	}
+	for status, c := range codes {
+		responses[fmt.Sprint(status)] = map[string]interface{}{
+			"description": fmt.Sprintf("%s: %s", http.StatusText(status), strings.Join(c, ", ")),
+			"content": map[string]interface{}{
+				problemContentType: map[string]interface{}{"schema": problemSchema},
+			},
+		}
+	}
+	for _, res := range responses {
+		res.(map[string]interface{})["headers"] = map[string]interface{}{
+			requestIDHeader: map[string]interface{}{"$ref": "#/components/headers/RequestID"},
+		}
+	}
+	doc["responses"] = responses
+
+	return doc
+}
+
+// operationID names an operation after its method and path, e.g.
+// getOrdersById for GET /orders/{id}.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '.' || r == '_' }) {
+		if strings.HasPrefix(part, "{") {
+			b.WriteString("By")
+			part = strings.Trim(part, "{}")
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+func componentRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+)
+
+// typeSchema describes the JSON encoding of t. Named structs are added to
+// schemas and referenced.
+func typeSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case objectIDType:
+		return map[string]interface{}{"type": "string", "pattern": "^[0-9a-f]{24}$"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), schemas)
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), schemas)}
+	case reflect.Map:
+		schema := map[string]interface{}{"type": "object"}
+		if t.Key().Kind() != reflect.String {
+			schema["propertyNames"] = map[string]interface{}{"pattern": "^[0-9]+$"}
+		}
+		if t.Elem().Kind() != reflect.Interface {
+			schema["additionalProperties"] = typeSchema(t.Elem(), schemas)
+		}
+		return schema
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := schemas[name]; !ok {
+			// Taken before the fields are described, for recursive types
+			schemas[name] = nil
+			schemas[name] = structSchema(t, schemas)
+		}
+		return componentRef(name)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema describes the fields of t as encoding/json writes them.
+// Fields without omitempty are required.
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]jsonField)
+	addStructFields(t, fields, 0)
+
+	properties := make(map[string]interface{}, len(fields))
+	var required []string
+	for name, f := range fields {
+		properties[name] = typeSchema(f.Type, schemas)
+		if !f.omitEmpty {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// jsonField is a field encoding/json writes, found depth embedded structs
+// down.
+type jsonField struct {
+	reflect.StructField
+	omitEmpty bool
+	depth     int
+}
+
+// addStructFields adds the fields of t, which is depth embedded structs down,
+// to fields. As with encoding/json, a field hides those of the same name
+// further down.
+func addStructFields(t reflect.Type, fields map[string]jsonField, depth int) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			addStructFields(f.Type, fields, depth+1)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		if existing, ok := fields[name]; ok && existing.depth <= depth {
+			continue
+		}
+		fields[name] = jsonField{StructField: f, omitEmpty: strings.Contains(opts, "omitempty"), depth: depth}
+	}
+}
+
+// HandleOpenAPI serves the OpenAPI document of the named API version, served
+// below versionPrefix.
+func (s *Server) HandleOpenAPI(pathPrefix, versionPrefix, version string) http.HandlerFunc {
+	doc, err := openAPIDocument(pathPrefix, versionPrefix, version)
+	var b []byte
+	if err == nil {
+		b, err = json.Marshal(doc)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			s.writeInternalError(w, r, fmt.Errorf("error building OpenAPI document: %w", err), "failed to build OpenAPI document")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+	}
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"packs-api/internal/config"
+	"packs-api/internal/resources"
+	"packs-api/internal/utils"
+)
+
+var updateOpenAPI = flag.Bool("update", false, "rewrite testdata/openapi.json")
+
+const openAPIFile = "testdata/openapi.json"
+
+// TestOpenAPIDocument fails when the document changes, e.g. because a
+// resources type did, until testdata/openapi.json is rewritten with
+// go test ./api -run TestOpenAPIDocument -update
+func TestOpenAPIDocument(t *testing.T) {
+	doc, err := openAPIDocument("/api", "/api/v1", "v1")
+	assert.Nil(t, err)
+	b, err := json.MarshalIndent(doc, "", "  ")
+	assert.Nil(t, err)
+	b = append(b, '\n')
+
+	if *updateOpenAPI {
+		assert.Nil(t, os.WriteFile(openAPIFile, b, 0o644))
+	}
+
+	want, err := os.ReadFile(openAPIFile)
+	assert.Nil(t, err)
+	// Compared as strings, as a diff of the whole document is unreadable
+	assert.True(t, string(want) == string(b), "the OpenAPI document changed, rerun the test with -update and review the diff")
+}
+
+func TestOpenAPIDocument_Routes(t *testing.T) {
+	cfg := &config.Config{PathPrefix: "/api", APIVersions: []config.APIVersion{{Name: "v1"}, {Name: "v2"}}}
+	s := NewServer(cfg, utils.NewLogger("test", "packs-api"))
+
+	var routes []string
+	err := s.router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// A subrouter's prefix
+			return nil
+		}
+		for _, m := range methods {
+			routes = append(routes, m+" "+tpl)
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+
+	var documented []string
+	for _, op := range openAPIOperations {
+		if op.unversioned {
+			documented = append(documented, op.method+" /api"+op.path)
+			continue
+		}
+		for _, prefix := range []string{"/api", "/api/v1", "/api/v2"} {
+			documented = append(documented, op.method+" "+prefix+op.path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+	assert.Equal(t, routes, documented, "every route registered in NewServer must be in openAPIOperations")
+}
+
+func TestOpenAPIDocument_Schemas(t *testing.T) {
+	doc, err := openAPIDocument("/api", "/api/v1", "v1")
+	assert.Nil(t, err)
+	b, err := json.Marshal(doc)
+	assert.Nil(t, err)
+
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	assert.Nil(t, c.AddResource("openapi.json", bytes.NewReader(b)))
+
+	maxOverage := 10
+	now := time.Date(2023, 11, 04, 20, 34, 58, 651387237, time.UTC)
+	tests := []struct {
+		schema string
+		value  interface{}
+	}{
+		{"Order", resources.Order{
+			ID: primitive.NewObjectID(), Items: 10, PackSizes: []int{1, 3}, PackQuantity: map[int]int{1: 1, 3: 3},
+			Packs: []resources.Pack{{Size: 1, Quantity: 1}, {Size: 3, Quantity: 3}}, TotalPacks: 4, ItemsShipped: 10,
+			Objective: "min_cost", PackCosts: map[int]int{1: 1, 3: 2}, MaxOverage: &maxOverage, CreatedAt: now, UpdatedAt: now,
+		}},
+		{"Quote", resources.Quote{
+			Items: 1, PackSizes: []int{1},
+			PackingSolution: resources.PackingSolution{Packs: []resources.Pack{{Size: 1, Quantity: 1}}, PackQuantity: map[int]int{1: 1}, TotalPacks: 1, ItemsShipped: 1, Objective: "min_overage"},
+		}},
+		{"Problem", newProblem(problemValidationFailed, "items: must be an integer")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			schema, err := c.Compile("openapi.json#/components/schemas/" + tt.schema)
+			assert.Nil(t, err)
+
+			b, err := json.Marshal(tt.value)
+			assert.Nil(t, err)
+			var v interface{}
+			assert.Nil(t, json.Unmarshal(b, &v))
+			assert.Nil(t, schema.Validate(v))
+		})
+	}
+}
+
+func TestOpenAPIDocument_Versions(t *testing.T) {
+	tests := []struct {
+		version string
+		order   string
+		quote   string
+	}{
+		{"v1", "Order", "Quote"},
+		{"v2", "OrderV2", "QuoteV2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			doc, err := openAPIDocument("/api", "/api/"+tt.version, tt.version)
+			assert.Nil(t, err)
+
+			data := func(path, method, status string) interface{} {
+				op := doc["paths"].(map[string]interface{})[path].(map[string]interface{})[method].(map[string]interface{})
+				res := op["responses"].(map[string]interface{})[status].(map[string]interface{})
+				schema := res["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+				return schema["properties"].(map[string]interface{})["data"]
+			}
+			assert.Equal(t, componentRef(tt.order), data("/orders/{id}", "get", "200"))
+			assert.Equal(t, componentRef(tt.order), data("/orders", "post", "201"))
+			assert.Equal(t, componentRef(tt.quote), data("/quotes", "post", "200"))
+		})
+	}
+
+	// The packs of OrderV2 hide those of the Order it embeds
+	doc, err := openAPIDocument("/api", "/api/v2", "v2")
+	assert.Nil(t, err)
+	b, err := json.Marshal(doc)
+	assert.Nil(t, err)
+
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	assert.Nil(t, c.AddResource("openapi.json", bytes.NewReader(b)))
+	schema, err := c.Compile("openapi.json#/components/schemas/OrderV2")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	now := time.Date(2023, 11, 04, 20, 34, 58, 651387237, time.UTC)
+	order := resources.Order{
+		ID: primitive.NewObjectID(), Items: 10, PackSizes: []int{1, 3}, PackQuantity: map[int]int{1: 1, 3: 3},
+		Packs: []resources.Pack{{Size: 1, Quantity: 1}, {Size: 3, Quantity: 3}}, TotalPacks: 4, ItemsShipped: 10,
+		Objective: "min_overage", CreatedAt: now, UpdatedAt: now,
+	}
+	validate := func(v interface{}) error {
+		b, err := json.Marshal(v)
+		assert.Nil(t, err)
+		var value interface{}
+		assert.Nil(t, json.Unmarshal(b, &value))
+		return schema.Validate(value)
+	}
+	assert.Nil(t, validate(resources.OrderV2{Order: order, Packs: newPackLines(order.Packs, nil)}))
+	assert.NotNil(t, validate(order), "the packs of a v1 order have no items")
+}
+
+func TestServer_HandleOpenAPI(t *testing.T) {
+	s := new(Server)
+	s.Log = utils.NewLogger("test", "packs-api")
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	s.HandleOpenAPI("/api", "/api/v1", "v1")(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var res map[string]interface{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	assert.Equal(t, "3.1.0", res["openapi"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"url": "/api/v1"}}, res["servers"])
+}
@@ -1,13 +1,15 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"path"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"packs-api/internal/resources"
-	"packs-api/internal/services"
 	"packs-api/internal/store"
 )
 
@@ -15,91 +17,222 @@ func (s *Server) HandleCreateOrder(mongoDB store.NoSQLStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		if !s.HasContentType(r, "application/json") {
-			s.WriteJSONError(w, http.StatusUnsupportedMediaType, "Unsupported media type")
+		orderRequest, ok := s.decodeOrderRequest(w, r)
+		if !ok {
 			return
 		}
 
-		if r.Body == nil {
-			s.WriteJSONError(w, http.StatusBadRequest, "Request body is empty")
+		result, ok := s.packOrderRequest(w, r, orderRequest)
+		if !ok {
 			return
 		}
 
-		b, err := io.ReadAll(r.Body)
-		defer func() {
-			_ = r.Body.Close()
-		}()
-		if err != nil {
-			s.WriteJSONError(w, http.StatusBadRequest, err.Error())
-			return
+		var order resources.Order
+		now := s.Time.Now()
+		order.ID = s.ObjectIDGenerator.GenerateRandomObjectID()
+		setOrderPacking(&order, orderRequest, result)
+		if caller := CallerFromContext(ctx); caller != nil {
+			order.CreatedBy = caller.ID
 		}
+		order.CreatedAt = now
+		order.UpdatedAt = now
 
-		var orderRequest *resources.OrderRequest
-		err = json.Unmarshal(b, &orderRequest)
+		err := mongoDB.CreateOrder(ctx, &order)
 		if err != nil {
-			s.WriteJSONError(w, http.StatusBadRequest, err.Error())
+			s.writeInternalError(w, r, fmt.Errorf("error creating order: %w", err), "failed to create order")
 			return
 		}
 
-		packs := services.GetPacks(orderRequest.Items, orderRequest.PackSizes)
+		w.Header().Set("Location", path.Join(r.URL.Path, order.ID.Hex()))
+		s.writeJSONData(w, http.StatusCreated, orderResponse(r, &order))
+	}
+
+}
+
+// HandleGetAllOrders lists orders one page at a time, see parseOrderQuery for
+// the supported query parameters.
+func (s *Server) HandleGetAllOrders(mongoDB store.NoSQLStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
 
-		if len(packs) < 1 {
-			s.Log.Error("no packs to ship")
-			s.WriteJSONError(w, http.StatusBadRequest, "no packs to ship")
+		query, err := parseOrderQuery(r.URL.Query())
+		if err != nil {
+			s.writeError(w, r, problemInvalidQuery, err.Error())
 			return
 		}
 
-		var order resources.Order
-		now := s.Time.Now()
-		order.ID = s.ObjectIDGenerator.GenerateRandomObjectID()
-		order.Items = orderRequest.Items
-		order.PackSizes = orderRequest.PackSizes
-		order.PackQuantity = packs
-		order.CreatedAt = now
-		order.UpdatedAt = now
+		s.logger(r.Context()).Info("getting all orders")
+
+		// One extra order tells whether there is a next page
+		limit := query.Limit
+		query.Limit++
 
-		err = mongoDB.CreateOrder(ctx, &order)
+		orders, err := mongoDB.GetAllOrders(ctx, query)
 		if err != nil {
-			err := fmt.Errorf("error creating order: %w", err)
-			s.Log.WithField("error", err.Error()).Error("failed to create order")
-			s.WriteJSONError(w, http.StatusInternalServerError, err.Error())
+			s.writeInternalError(w, r, fmt.Errorf("error getting all orders: %w", err), "failed to get all orders")
 			return
 		}
 
-		w.WriteHeader(http.StatusCreated)
+		s.logger(r.Context()).Info("orders retrieved successfully")
+
+		paging := Paging{Limit: limit}
+		if len(orders) > limit {
+			orders = orders[:limit]
+			paging.HasMore = true
+			paging.NextCursor = encodeOrderCursor(orders[limit-1])
+		}
+		paging.Count = len(orders)
+
+		s.writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data":   ordersResponse(r, orders),
+			"paging": paging,
+		})
 	}
+}
+
+func (s *Server) HandleGetOrder(mongoDB store.NoSQLStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := s.parseOrderID(w, r)
+		if !ok {
+			return
+		}
 
+		order, ok := s.getOrder(w, r, mongoDB, id)
+		if !ok {
+			return
+		}
+
+		s.writeJSONData(w, http.StatusOK, orderResponse(r, order))
+	}
 }
 
-func (s *Server) HandleGetAllOrders(mongoDB store.NoSQLStore) http.HandlerFunc {
+// HandleUpdateOrder changes the items, pack sizes, stock or costs of an order
+// and packs it again with the order's objective.
+func (s *Server) HandleUpdateOrder(mongoDB store.NoSQLStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		s.Log.Info("getting all orders")
+		id, ok := s.parseOrderID(w, r)
+		if !ok {
+			return
+		}
+
+		var patch resources.OrderPatchRequest
+		if !s.decodeJSONBody(w, r, orderPatchRequestSchema, &patch) {
+			return
+		}
 
-		orders, err := mongoDB.GetAllOrders(ctx)
+		if patch.Items == nil && patch.PackSizes == nil && patch.Availability == nil && patch.PackCosts == nil {
+			s.writeError(w, r, problemValidationFailed, "nothing to update")
+			return
+		}
+
+		order, ok := s.getOrder(w, r, mongoDB, id)
+		if !ok {
+			return
+		}
+
+		orderRequest := &resources.OrderRequest{
+			Items:        order.Items,
+			PackSizes:    order.PackSizes,
+			Availability: order.Availability,
+			Objective:    order.Objective,
+			PackCosts:    order.PackCosts,
+			MaxOverage:   order.MaxOverage,
+		}
+		if patch.Items != nil {
+			orderRequest.Items = *patch.Items
+		}
+		if patch.PackSizes != nil {
+			orderRequest.PackSizes = patch.PackSizes
+		}
+		if patch.Availability != nil {
+			orderRequest.Availability = patch.Availability
+		}
+		if patch.PackCosts != nil {
+			orderRequest.PackCosts = patch.PackCosts
+		}
+
+		result, ok := s.packOrderRequest(w, r, orderRequest)
+		if !ok {
+			return
+		}
+
+		setOrderPacking(order, orderRequest, result)
+		order.UpdatedAt = s.Time.Now()
+
+		err := mongoDB.UpdateOrder(ctx, order)
+		if errors.Is(err, store.ErrNotFound) {
+			s.writeError(w, r, problemOrderNotFound, "order not found")
+			return
+		}
 		if err != nil {
-			err := fmt.Errorf("error getting all orders: %w", err)
-			s.Log.WithField("error", err.Error()).Error("failed to get all orders")
-			s.WriteJSONError(w, http.StatusInternalServerError, err.Error())
+			s.writeInternalError(w, r, fmt.Errorf("error updating order: %w", err), "failed to update order")
 			return
 		}
 
-		s.Log.Info("orders retrieved successfully")
+		s.writeJSONData(w, http.StatusOK, orderResponse(r, order))
+	}
+}
 
-		data := map[string]interface{}{
-			"data": orders,
+func (s *Server) HandleDeleteOrder(mongoDB store.NoSQLStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, ok := s.parseOrderID(w, r)
+		if !ok {
+			return
 		}
 
-		jsonResponse, err := json.Marshal(data)
+		err := mongoDB.DeleteOrder(ctx, id)
+		if errors.Is(err, store.ErrNotFound) {
+			s.writeError(w, r, problemOrderNotFound, "order not found")
+			return
+		}
 		if err != nil {
-			s.Log.WithField("error", err.Error()).Error("invalid response body")
-			s.WriteJSONError(w, http.StatusInternalServerError, err.Error())
+			s.writeInternalError(w, r, fmt.Errorf("error deleting order: %w", err), "failed to delete order")
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write(jsonResponse)
+		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// parseOrderID reads the order ID from the route. On failure it writes the
+// error response and returns false.
+func (s *Server) parseOrderID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	id, err := s.ObjectIDGenerator.ParseObjectID(mux.Vars(r)["id"])
+	if err != nil {
+		s.writeError(w, r, problemInvalidOrderID, "invalid order id")
+		return primitive.NilObjectID, false
+	}
+
+	return id, true
+}
+
+// getOrder loads the order from the store. On failure it writes the error
+// response and returns false.
+func (s *Server) getOrder(w http.ResponseWriter, r *http.Request, mongoDB store.NoSQLStore, id primitive.ObjectID) (*resources.Order, bool) {
+	order, err := mongoDB.GetOrder(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		s.writeError(w, r, problemOrderNotFound, "order not found")
+		return nil, false
+	}
+	if err != nil {
+		s.writeInternalError(w, r, fmt.Errorf("error getting order: %w", err), "failed to get order")
+		return nil, false
+	}
+
+	return order, true
+}
+
+// decodeOrderRequest reads the JSON order request from the body. On failure it
+// writes the error response and returns false.
+func (s *Server) decodeOrderRequest(w http.ResponseWriter, r *http.Request) (*resources.OrderRequest, bool) {
+	var orderRequest resources.OrderRequest
+	if !s.decodeJSONBody(w, r, orderRequestSchema, &orderRequest) {
+		return nil, false
+	}
+
+	return &orderRequest, true
+}
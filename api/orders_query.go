@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"packs-api/internal/resources"
+	"packs-api/internal/store"
+)
+
+const (
+	defaultOrdersLimit = 50
+	maxOrdersLimit     = 200
+)
+
+var orderSortFields = map[string]store.OrderSortField{
+	"createdAt": store.SortByCreatedAt,
+	"items":     store.SortByItems,
+}
+
+// Paging describes one page of a listing. NextCursor is empty on the last
+// page.
+type Paging struct {
+	Limit      int    `json:"limit"`
+	Count      int    `json:"count"`
+	HasMore    bool   `json:"hasMore"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// parseOrderQuery reads the listing options of GET /orders:
+// limit, after, createdFrom, createdTo, minItems, maxItems, packSize and sort
+// (createdAt or items, prefixed with - for descending order).
+func parseOrderQuery(values url.Values) (store.OrderQuery, error) {
+	query := store.OrderQuery{Limit: defaultOrdersLimit}
+
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxOrdersLimit {
+			return query, fmt.Errorf("limit must be a number between 1 and %d", maxOrdersLimit)
+		}
+		query.Limit = n
+	}
+
+	if v := values.Get("after"); v != "" {
+		cursor, err := decodeOrderCursor(v)
+		if err != nil {
+			return query, errors.New("after must be a cursor returned by a previous page")
+		}
+		query.After = cursor
+	}
+
+	var err error
+	if query.CreatedFrom, err = parseTimeParam(values, "createdFrom"); err != nil {
+		return query, err
+	}
+	if query.CreatedTo, err = parseTimeParam(values, "createdTo"); err != nil {
+		return query, err
+	}
+	if query.MinItems, err = parseIntParam(values, "minItems"); err != nil {
+		return query, err
+	}
+	if query.MaxItems, err = parseIntParam(values, "maxItems"); err != nil {
+		return query, err
+	}
+	if query.PackSize, err = parseIntParam(values, "packSize"); err != nil {
+		return query, err
+	}
+
+	if v := values.Get("sort"); v != "" {
+		name := v
+		if name[0] == '-' {
+			query.SortDesc = true
+			name = name[1:]
+		}
+		field, ok := orderSortFields[name]
+		if !ok {
+			return query, errors.New("sort must be one of createdAt, -createdAt, items or -items")
+		}
+		query.SortBy = field
+	}
+
+	return query, nil
+}
+
+func parseTimeParam(values url.Values, name string) (*time.Time, error) {
+	v := values.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+
+	return &t, nil
+}
+
+func parseIntParam(values url.Values, name string) (*int, error) {
+	v := values.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", name)
+	}
+
+	return &n, nil
+}
+
+func encodeOrderCursor(order *resources.Order) string {
+	b, _ := json.Marshal(store.OrderCursor{ID: order.ID, CreatedAt: order.CreatedAt, Items: order.Items})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeOrderCursor(s string) (*store.OrderCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor store.OrderCursor
+	err = json.Unmarshal(b, &cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"packs-api/internal/resources"
+	"packs-api/internal/store"
 	"packs-api/internal/utils"
 	"packs-api/mocks"
 )
@@ -37,8 +39,12 @@ func TestServer_HandleGetAllOrders(t *testing.T) {
 			1: 1,
 			3: 3,
 		},
-		CreatedAt: freezedTime.Now(),
-		UpdatedAt: freezedTime.Now(),
+		Packs:        []resources.Pack{{Size: 1, Quantity: 1}, {Size: 3, Quantity: 3}},
+		TotalPacks:   4,
+		ItemsShipped: 10,
+		Objective:    "min_overage",
+		CreatedAt:    freezedTime.Now(),
+		UpdatedAt:    freezedTime.Now(),
 	}
 
 	orderTwo := &resources.Order{
@@ -49,8 +55,12 @@ func TestServer_HandleGetAllOrders(t *testing.T) {
 			2: 1,
 			3: 6,
 		},
-		CreatedAt: freezedTime.Now().AddDate(0, 0, 1),
-		UpdatedAt: freezedTime.Now().AddDate(0, 0, 1),
+		Packs:        []resources.Pack{{Size: 2, Quantity: 1}, {Size: 3, Quantity: 6}},
+		TotalPacks:   7,
+		ItemsShipped: 20,
+		Objective:    "min_overage",
+		CreatedAt:    freezedTime.Now().AddDate(0, 0, 1),
+		UpdatedAt:    freezedTime.Now().AddDate(0, 0, 1),
 	}
 
 	orders := []*resources.Order{orderOne, orderTwo}
@@ -58,12 +68,12 @@ func TestServer_HandleGetAllOrders(t *testing.T) {
 	mongoDB := mocks.NewMockNoSQLStore(ctrl)
 	mongoDB.
 		EXPECT().
-		GetAllOrders(gomock.Any()).
+		GetAllOrders(gomock.Any(), store.OrderQuery{Limit: 51}).
 		Return(nil, errors.New("store error")).
 		Times(1)
 	mongoDB.
 		EXPECT().
-		GetAllOrders(gomock.Any()).
+		GetAllOrders(gomock.Any(), store.OrderQuery{Limit: 51}).
 		Return(orders, nil).
 		Times(1)
 
@@ -74,12 +84,13 @@ func TestServer_HandleGetAllOrders(t *testing.T) {
 	s.Log = logger
 
 	tests := []struct {
-		name     string
-		status   int
-		errorMsg string
+		name      string
+		status    int
+		errorCode string
+		errorMsg  string
 	}{
-		{"error", 500, "error getting all orders: store error"},
-		{"success", 200, ""},
+		{"error", 500, "internal_error", internalErrorDetail},
+		{"success", 200, "", ""},
 	}
 
 	for _, tt := range tests {
@@ -93,46 +104,169 @@ func TestServer_HandleGetAllOrders(t *testing.T) {
 
 		assert.Equal(t, tt.status, rr.Code)
 
+		if tt.errorCode != "" {
+			assertProblem(t, rr, tt.errorCode, tt.errorMsg)
+			continue
+		}
+
 		b, _ := io.ReadAll(rr.Body)
 		var res map[string]interface{}
 		err := json.Unmarshal(b, &res)
 		assert.Nil(t, err)
 
-		var expected map[string]interface{}
-		if tt.errorMsg == "" {
-			expected = map[string]interface{}{
-				"data": []interface{}{
-					map[string]interface{}{
-						"createdAt": "2023-11-04T20:34:58.651387237Z",
-						"id":        orderOneID.Hex(),
-						"items":     float64(10),
-						"packQuantity": map[string]interface{}{
-							"1": float64(1),
-							"3": float64(3),
-						},
-						"packSizes": []interface{}{float64(1), float64(2), float64(3)},
-						"updatedAt": "2023-11-04T20:34:58.651387237Z",
+		expected := map[string]interface{}{
+			"data": []interface{}{
+				map[string]interface{}{
+					"createdAt": "2023-11-04T20:34:58.651387237Z",
+					"id":        orderOneID.Hex(),
+					"items":     float64(10),
+					"packQuantity": map[string]interface{}{
+						"1": float64(1),
+						"3": float64(3),
 					},
-					map[string]interface{}{
-						"createdAt": "2023-11-05T20:34:58.651387237Z",
-						"id":        orderTwoID.Hex(),
-						"items":     float64(20),
-						"packQuantity": map[string]interface{}{
-							"2": float64(1),
-							"3": float64(6),
-						},
-						"packSizes": []interface{}{float64(1), float64(2), float64(3)},
-						"updatedAt": "2023-11-05T20:34:58.651387237Z",
+					"packSizes": []interface{}{float64(1), float64(2), float64(3)},
+					"packs": []interface{}{
+						map[string]interface{}{"size": float64(1), "quantity": float64(1)},
+						map[string]interface{}{"size": float64(3), "quantity": float64(3)},
 					},
+					"totalPacks":   float64(4),
+					"itemsShipped": float64(10),
+					"overage":      float64(0),
+					"objective":    "min_overage",
+					"updatedAt":    "2023-11-04T20:34:58.651387237Z",
 				},
-			}
-		} else {
-			expected = map[string]interface{}{"error": true, "code": float64(rr.Code), "message": tt.errorMsg}
+				map[string]interface{}{
+					"createdAt": "2023-11-05T20:34:58.651387237Z",
+					"id":        orderTwoID.Hex(),
+					"items":     float64(20),
+					"packQuantity": map[string]interface{}{
+						"2": float64(1),
+						"3": float64(6),
+					},
+					"packSizes": []interface{}{float64(1), float64(2), float64(3)},
+					"packs": []interface{}{
+						map[string]interface{}{"size": float64(2), "quantity": float64(1)},
+						map[string]interface{}{"size": float64(3), "quantity": float64(6)},
+					},
+					"totalPacks":   float64(7),
+					"itemsShipped": float64(20),
+					"overage":      float64(0),
+					"objective":    "min_overage",
+					"updatedAt":    "2023-11-05T20:34:58.651387237Z",
+				},
+			},
+			"paging": map[string]interface{}{
+				"limit":   float64(50),
+				"count":   float64(2),
+				"hasMore": false,
+			},
 		}
 		assert.Equal(t, expected, res)
 	}
 }
 
+func TestServer_HandleGetAllOrders_Query(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	createdAt := time.Date(2023, 11, 04, 20, 34, 58, 0, time.UTC)
+	orderOne := &resources.Order{ID: primitive.NewObjectID(), Items: 10, CreatedAt: createdAt}
+	orderTwo := &resources.Order{ID: primitive.NewObjectID(), Items: 20, CreatedAt: createdAt}
+	cursor := encodeOrderCursor(orderOne)
+
+	from := time.Date(2023, 11, 01, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 12, 01, 0, 0, 0, 0, time.UTC)
+	minItems, maxItems, packSize := 5, 100, 250
+
+	mongoDB := mocks.NewMockNoSQLStore(ctrl)
+	mongoDB.
+		EXPECT().
+		GetAllOrders(gomock.Any(), store.OrderQuery{Limit: 2}).
+		Return([]*resources.Order{orderOne, orderTwo}, nil).
+		Times(1)
+	mongoDB.
+		EXPECT().
+		GetAllOrders(gomock.Any(), store.OrderQuery{
+			Limit:       2,
+			After:       &store.OrderCursor{ID: orderOne.ID, CreatedAt: createdAt, Items: 10},
+			CreatedFrom: &from,
+			CreatedTo:   &to,
+			MinItems:    &minItems,
+			MaxItems:    &maxItems,
+			PackSize:    &packSize,
+			SortBy:      store.SortByItems,
+			SortDesc:    true,
+		}).
+		Return([]*resources.Order{orderTwo}, nil).
+		Times(1)
+
+	s := new(Server)
+	s.Log = utils.NewLogger("test", "packs-api")
+
+	tests := []struct {
+		name      string
+		query     string
+		status    int
+		expected  map[string]interface{}
+		errorCode string
+		errorMsg  string
+	}{
+		{
+			"first page", "?limit=1", 200,
+			map[string]interface{}{"limit": float64(1), "count": float64(1), "hasMore": true, "nextCursor": cursor}, "", "",
+		},
+		{
+			"filtered last page",
+			"?limit=1&after=" + cursor + "&createdFrom=2023-11-01T00:00:00Z&createdTo=2023-12-01T00:00:00Z&minItems=5&maxItems=100&packSize=250&sort=-items",
+			200,
+			map[string]interface{}{"limit": float64(1), "count": float64(1), "hasMore": false}, "", "",
+		},
+		{
+			"invalid limit", "?limit=500", 400,
+			nil, "invalid_query", "limit must be a number between 1 and 200",
+		},
+		{
+			"invalid cursor", "?after=abc", 400,
+			nil, "invalid_query", "after must be a cursor returned by a previous page",
+		},
+		{
+			"invalid date", "?createdFrom=yesterday", 400,
+			nil, "invalid_query", "createdFrom must be an RFC 3339 timestamp",
+		},
+		{
+			"invalid pack size", "?packSize=big", 400,
+			nil, "invalid_query", "packSize must be a number",
+		},
+		{
+			"invalid sort", "?sort=overage", 400,
+			nil, "invalid_query", "sort must be one of createdAt, -createdAt, items or -items",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/api/orders"+tt.query, nil)
+
+			rr := httptest.NewRecorder()
+			router := mux.NewRouter()
+			router.HandleFunc("/api/orders", s.HandleGetAllOrders(mongoDB)).Methods(http.MethodGet)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+
+			if tt.errorCode != "" {
+				assertProblem(t, rr, tt.errorCode, tt.errorMsg)
+				return
+			}
+
+			var res map[string]interface{}
+			err := json.Unmarshal(rr.Body.Bytes(), &res)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, res["paging"])
+			assert.Len(t, res["data"], 1)
+		})
+	}
+}
+
 func TestServer_HandleCreateOrder(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	freezedTime := mocks.NewMockTime(ctrl)
@@ -155,8 +289,12 @@ func TestServer_HandleCreateOrder(t *testing.T) {
 			1: 1,
 			3: 3,
 		},
-		CreatedAt: freezedTime.Now(),
-		UpdatedAt: freezedTime.Now(),
+		Packs:        []resources.Pack{{Size: 1, Quantity: 1}, {Size: 3, Quantity: 3}},
+		TotalPacks:   4,
+		ItemsShipped: 10,
+		Objective:    "min_overage",
+		CreatedAt:    freezedTime.Now(),
+		UpdatedAt:    freezedTime.Now(),
 	}
 
 	mongoDB := mocks.NewMockNoSQLStore(ctrl)
@@ -177,19 +315,35 @@ func TestServer_HandleCreateOrder(t *testing.T) {
 	s.ObjectIDGenerator = objectIDGenerator
 	s.Time = freezedTime
 	s.Log = logger
+	s.MaxPackSize = 10000
+	s.MaxBodyBytes = 256
 
 	tests := []struct {
 		name        string
 		contentType string
 		requestBody []byte
 		status      int
+		errorCode   string
 		errorMsg    string
 	}{
-		{"unsupported media type", "application/xml", nil, 415, "Unsupported media type"},
-		{"empty request body", "application/json", nil, 400, "unexpected end of JSON input"},
-		{"invalid json", "application/json", []byte(`{"items": "a"}`), 400, "json: cannot unmarshal string into Go struct field OrderRequest.items of type int"},
-		{"error creating order", "application/json", []byte(`{"items": 10, "packSizes": [1, 2, 3]}`), 500, "error creating order: store error"},
-		{"success", "application/json", []byte(`{"items": 10, "packSizes": [1, 2, 3]}`), 201, ""},
+		{"unsupported media type", "application/xml", nil, 415, "unsupported_media_type", "Content-Type must be application/json"},
+		{"empty request body", "application/json", nil, 400, "malformed_body", "request body is empty"},
+		{"invalid json", "application/json", []byte(`{"items": 10,`), 400, "malformed_body", "invalid JSON at offset 13"},
+		{"body too large", "application/json", []byte(`{"items": 10, "packSizes": [` + strings.Repeat("1, ", 100) + `1]}`), 413, "body_too_large", "request body must be at most 256 bytes"},
+		{"wrong type", "application/json", []byte(`{"items": "a", "packSizes": [1]}`), 400, "validation_failed", "items: expected integer, but got string"},
+		{"misspelt field", "application/json", []byte(`{"items": 10, "packsizes": [1]}`), 400, "validation_failed", "missing properties: 'packSizes'; additionalProperties 'packsizes' not allowed"},
+		{"zero items", "application/json", []byte(`{"items": 0, "packSizes": [1, 2, 3]}`), 400, "validation_failed", "items must be greater than zero"},
+		{"no pack sizes", "application/json", []byte(`{"items": 10, "packSizes": []}`), 400, "validation_failed", "at least one pack size is required"},
+		{"non-positive pack size", "application/json", []byte(`{"items": 10, "packSizes": [1, 0]}`), 400, "validation_failed", "pack sizes must be greater than zero: 0"},
+		{"duplicate pack size", "application/json", []byte(`{"items": 10, "packSizes": [1, 2, 2]}`), 400, "validation_failed", "pack sizes must be unique: 2"},
+		{"pack size above limit", "application/json", []byte(`{"items": 10, "packSizes": [1, 20000]}`), 400, "validation_failed", "pack size exceeds the maximum allowed of 10000: 20000"},
+		{"negative stock", "application/json", []byte(`{"items": 10, "packSizes": [1, 2], "availability": {"2": -1}}`), 400, "validation_failed", "stock must not be negative: 2"},
+		{"unknown objective", "application/json", []byte(`{"items": 10, "packSizes": [1, 2], "objective": "cheapest"}`), 400, "validation_failed", "unknown objective: \"cheapest\""},
+		{"missing cost", "application/json", []byte(`{"items": 10, "packSizes": [1, 2], "objective": "min_cost", "packCosts": {"1": 5}}`), 400, "validation_failed", "cost is required for every pack size: 2"},
+		{"cost too large", "application/json", []byte(`{"items": 2, "packSizes": [1, 2], "objective": "min_cost", "packCosts": {"1": 5000000000000000000, "2": 9000000000000000000}}`), 400, "validation_failed", "cost exceeds the maximum allowed of 1000000000: 1"},
+		{"overage not allowed", "application/json", []byte(`{"items": 10, "packSizes": [4], "objective": "min_cost_max_overage", "packCosts": {"4": 5}, "maxOverage": 1}`), 422, "overage_not_allowed", "no combination of pack sizes covers the order within the allowed overage"},
+		{"error creating order", "application/json", []byte(`{"items": 10, "packSizes": [1, 2, 3]}`), 500, "internal_error", internalErrorDetail},
+		{"success", "application/json", []byte(`{"items": 10, "packSizes": [1, 2, 3]}`), 201, "", ""},
 	}
 
 	for _, tt := range tests {
@@ -205,11 +359,393 @@ func TestServer_HandleCreateOrder(t *testing.T) {
 			assert.Equal(t, tt.status, rr.Code)
 
 			if tt.errorMsg != "" {
-				var res map[string]interface{}
+				assertProblem(t, rr, tt.errorCode, tt.errorMsg)
+			}
+
+			if tt.status == http.StatusCreated {
+				assert.Equal(t, "/api/orders/"+orderID.Hex(), rr.Header().Get("Location"))
+
+				var res struct {
+					Data *resources.Order `json:"data"`
+				}
 				err := json.Unmarshal(rr.Body.Bytes(), &res)
 				assert.Nil(t, err)
-				expected := map[string]interface{}{"error": true, "code": float64(tt.status), "message": tt.errorMsg}
-				assert.Equal(t, expected, res)
+				assert.Equal(t, orderOne, res.Data)
+			}
+		})
+	}
+}
+
+func TestServer_HandleCreateOrder_CreatedBy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	freezedTime := mocks.NewMockTime(ctrl)
+	freezedTime.EXPECT().Now().Return(time.Date(2023, 11, 04, 20, 34, 58, 651387237, time.UTC)).AnyTimes()
+
+	objectIDGenerator := mocks.NewMockObjectIDGenerator(ctrl)
+	objectIDGenerator.EXPECT().GenerateRandomObjectID().Return(primitive.NewObjectID()).Times(1)
+
+	var created *resources.Order
+	mongoDB := mocks.NewMockNoSQLStore(ctrl)
+	mongoDB.
+		EXPECT().
+		CreateOrder(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, order *resources.Order) error {
+			created = order
+			return nil
+		}).
+		Times(1)
+
+	s := new(Server)
+	s.ObjectIDGenerator = objectIDGenerator
+	s.Time = freezedTime
+	s.Log = utils.NewLogger("test", "packs-api")
+
+	ctx := withCaller(context.Background(), &Caller{ID: "ann", Name: "Ann"})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/api/orders", bytes.NewReader([]byte(`{"items": 10, "packSizes": [1, 2, 3]}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	s.HandleCreateOrder(mongoDB)(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "ann", created.CreatedBy)
+	assert.Contains(t, rr.Body.String(), `"createdBy":"ann"`)
+}
+
+func TestServer_HandleCreateOrder_InsufficientStock(t *testing.T) {
+	logger := utils.NewLogger("test", "packs-api")
+
+	s := new(Server)
+	s.Log = logger
+
+	body := []byte(`{"items": 501, "packSizes": [250, 500, 1000], "availability": {"250": 1, "500": 0, "1000": 0}}`)
+	req, _ := http.NewRequest(http.MethodPost, "/api/orders", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/api/orders", s.HandleCreateOrder(nil)).Methods(http.MethodPost)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	res := assertProblem(t, rr, "insufficient_stock", "insufficient stock: available packs hold 250 of 501 items")
+	assert.Equal(t, float64(250), res["capacity"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"size": float64(500), "quantity": float64(1)},
+	}, res["shortfall"])
+}
+
+func TestServer_HandleGetOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	orderID := primitive.NewObjectID()
+	missingID := primitive.NewObjectID()
+	failingID := primitive.NewObjectID()
+
+	order := &resources.Order{
+		ID:           orderID,
+		Items:        10,
+		PackSizes:    []int{1, 2, 3},
+		PackQuantity: map[int]int{1: 1, 3: 3},
+		Packs:        []resources.Pack{{Size: 1, Quantity: 1}, {Size: 3, Quantity: 3}},
+		TotalPacks:   4,
+		ItemsShipped: 10,
+		Objective:    "min_overage",
+		CreatedAt:    time.Date(2023, 11, 04, 20, 34, 58, 651387237, time.UTC),
+		UpdatedAt:    time.Date(2023, 11, 04, 20, 34, 58, 651387237, time.UTC),
+	}
+
+	mongoDB := mocks.NewMockNoSQLStore(ctrl)
+	mongoDB.EXPECT().GetOrder(gomock.Any(), orderID).Return(order, nil).Times(1)
+	mongoDB.EXPECT().GetOrder(gomock.Any(), missingID).Return(nil, store.ErrNotFound).Times(1)
+	mongoDB.EXPECT().GetOrder(gomock.Any(), failingID).Return(nil, errors.New("store error")).Times(1)
+
+	s := new(Server)
+	s.ObjectIDGenerator = utils.NewRandomObjectIDGenerator()
+	s.Log = utils.NewLogger("test", "packs-api")
+
+	tests := []struct {
+		name      string
+		id        string
+		status    int
+		errorCode string
+		errorMsg  string
+	}{
+		{"invalid id", "abc", 400, "invalid_order_id", "invalid order id"},
+		{"not found", missingID.Hex(), 404, "order_not_found", "order not found"},
+		{"store error", failingID.Hex(), 500, "internal_error", internalErrorDetail},
+		{"success", orderID.Hex(), 200, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/api/orders/"+tt.id, nil)
+
+			rr := httptest.NewRecorder()
+			router := mux.NewRouter()
+			router.HandleFunc("/api/orders/{id}", s.HandleGetOrder(mongoDB)).Methods(http.MethodGet)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+
+			if tt.errorMsg != "" {
+				assertProblem(t, rr, tt.errorCode, tt.errorMsg)
+				return
+			}
+
+			var res struct {
+				Data *resources.Order `json:"data"`
+			}
+			err := json.Unmarshal(rr.Body.Bytes(), &res)
+			assert.Nil(t, err)
+			assert.Equal(t, order, res.Data)
+		})
+	}
+}
+
+func TestServer_HandleUpdateOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	freezedTime := mocks.NewMockTime(ctrl)
+	freezedTime.EXPECT().Now().Return(time.Date(2023, 11, 05, 10, 0, 0, 0, time.UTC)).AnyTimes()
+
+	orderID := primitive.NewObjectID()
+	missingID := primitive.NewObjectID()
+	createdAt := time.Date(2023, 11, 04, 20, 34, 58, 651387237, time.UTC)
+
+	storedOrder := func() *resources.Order {
+		return &resources.Order{
+			ID:           orderID,
+			Items:        10,
+			PackSizes:    []int{1, 2, 3},
+			PackQuantity: map[int]int{1: 1, 3: 3},
+			Packs:        []resources.Pack{{Size: 1, Quantity: 1}, {Size: 3, Quantity: 3}},
+			TotalPacks:   4,
+			ItemsShipped: 10,
+			Objective:    "min_overage",
+			CreatedAt:    createdAt,
+			UpdatedAt:    createdAt,
+		}
+	}
+
+	updatedOrder := &resources.Order{
+		ID:           orderID,
+		Items:        251,
+		PackSizes:    []int{250, 500},
+		PackQuantity: map[int]int{500: 1},
+		Packs:        []resources.Pack{{Size: 500, Quantity: 1}},
+		TotalPacks:   1,
+		ItemsShipped: 500,
+		Overage:      249,
+		Objective:    "min_overage",
+		CreatedAt:    createdAt,
+		UpdatedAt:    freezedTime.Now(),
+	}
+
+	mongoDB := mocks.NewMockNoSQLStore(ctrl)
+	mongoDB.EXPECT().GetOrder(gomock.Any(), missingID).Return(nil, store.ErrNotFound).Times(1)
+	mongoDB.EXPECT().GetOrder(gomock.Any(), orderID).DoAndReturn(
+		func(context.Context, primitive.ObjectID) (*resources.Order, error) { return storedOrder(), nil },
+	).Times(3)
+	mongoDB.EXPECT().UpdateOrder(gomock.Any(), updatedOrder).Return(errors.New("store error")).Times(1)
+	mongoDB.EXPECT().UpdateOrder(gomock.Any(), updatedOrder).Return(nil).Times(1)
+
+	s := new(Server)
+	s.ObjectIDGenerator = utils.NewRandomObjectIDGenerator()
+	s.Time = freezedTime
+	s.Log = utils.NewLogger("test", "packs-api")
+	s.MaxPackSize = 10000
+
+	tests := []struct {
+		name        string
+		id          string
+		requestBody []byte
+		status      int
+		errorCode   string
+		errorMsg    string
+	}{
+		{"invalid id", "abc", []byte(`{"items": 251}`), 400, "invalid_order_id", "invalid order id"},
+		{"nothing to update", orderID.Hex(), []byte(`{}`), 400, "validation_failed", "nothing to update"},
+		{"not found", missingID.Hex(), []byte(`{"items": 251}`), 404, "order_not_found", "order not found"},
+		{"invalid order", orderID.Hex(), []byte(`{"items": 0}`), 400, "validation_failed", "items must be greater than zero"},
+		{"error updating order", orderID.Hex(), []byte(`{"items": 251, "packSizes": [250, 500]}`), 500, "internal_error", internalErrorDetail},
+		{"success", orderID.Hex(), []byte(`{"items": 251, "packSizes": [250, 500]}`), 200, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPatch, "/api/orders/"+tt.id, bytes.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			router := mux.NewRouter()
+			router.HandleFunc("/api/orders/{id}", s.HandleUpdateOrder(mongoDB)).Methods(http.MethodPatch)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+
+			if tt.errorMsg != "" {
+				assertProblem(t, rr, tt.errorCode, tt.errorMsg)
+				return
+			}
+
+			var res struct {
+				Data *resources.Order `json:"data"`
+			}
+			err := json.Unmarshal(rr.Body.Bytes(), &res)
+			assert.Nil(t, err)
+			assert.Equal(t, updatedOrder, res.Data)
+		})
+	}
+}
+
+func TestServer_HandleUpdateOrder_Repack(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	freezedTime := mocks.NewMockTime(ctrl)
+	freezedTime.EXPECT().Now().Return(time.Date(2023, 11, 05, 10, 0, 0, 0, time.UTC)).AnyTimes()
+
+	orderID := primitive.NewObjectID()
+
+	mongoDB := mocks.NewMockNoSQLStore(ctrl)
+	mongoDB.EXPECT().GetOrder(gomock.Any(), orderID).DoAndReturn(
+		func(context.Context, primitive.ObjectID) (*resources.Order, error) {
+			return &resources.Order{
+				ID:           orderID,
+				Items:        10,
+				PackSizes:    []int{1, 2, 3},
+				Availability: map[int]int{3: 3},
+				Objective:    "min_cost",
+				PackCosts:    map[int]int{1: 1, 2: 2, 3: 2},
+			}, nil
+		},
+	).AnyTimes()
+	mongoDB.EXPECT().UpdateOrder(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	s := new(Server)
+	s.ObjectIDGenerator = utils.NewRandomObjectIDGenerator()
+	s.Time = freezedTime
+	s.Log = utils.NewLogger("test", "packs-api")
+	s.MaxPackSize = 10000
+
+	tests := []struct {
+		name         string
+		requestBody  []byte
+		status       int
+		errorMsg     string
+		packQuantity map[int]int
+		availability map[int]int
+	}{
+		{
+			name:        "new sizes without costs",
+			requestBody: []byte(`{"packSizes": [250, 500], "availability": {}}`),
+			status:      400,
+			errorMsg:    "cost given for a pack size that is not in the order: 1",
+		},
+		{
+			name:        "new sizes with stock of the old ones",
+			requestBody: []byte(`{"packSizes": [250, 500], "packCosts": {"250": 1, "500": 3}}`),
+			status:      400,
+			errorMsg:    "stock given for a pack size that is not in the order: 3",
+		},
+		{
+			name:         "new sizes with costs and stock",
+			requestBody:  []byte(`{"items": 251, "packSizes": [250, 500], "packCosts": {"250": 1, "500": 3}, "availability": {"250": 1}}`),
+			status:       200,
+			packQuantity: map[int]int{500: 1},
+			availability: map[int]int{250: 1},
+		},
+		{
+			name:         "stock kept",
+			requestBody:  []byte(`{"items": 10}`),
+			status:       200,
+			packQuantity: map[int]int{3: 3, 1: 1},
+			availability: map[int]int{3: 3},
+		},
+		{
+			name:         "less stock",
+			requestBody:  []byte(`{"availability": {"3": 1}}`),
+			status:       200,
+			packQuantity: map[int]int{3: 1, 2: 3, 1: 1},
+			availability: map[int]int{3: 1},
+		},
+		{
+			name:         "stock dropped",
+			requestBody:  []byte(`{"items": 12, "availability": {}}`),
+			status:       200,
+			packQuantity: map[int]int{3: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPatch, "/api/orders/"+orderID.Hex(), bytes.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			router := mux.NewRouter()
+			router.HandleFunc("/api/orders/{id}", s.HandleUpdateOrder(mongoDB)).Methods(http.MethodPatch)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+
+			if tt.errorMsg != "" {
+				assertProblem(t, rr, "validation_failed", tt.errorMsg)
+				return
+			}
+
+			var res struct {
+				Data *resources.Order `json:"data"`
+			}
+			err := json.Unmarshal(rr.Body.Bytes(), &res)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.packQuantity, res.Data.PackQuantity)
+			assert.Equal(t, tt.availability, res.Data.Availability)
+		})
+	}
+}
+
+func TestServer_HandleDeleteOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	orderID := primitive.NewObjectID()
+	missingID := primitive.NewObjectID()
+	failingID := primitive.NewObjectID()
+
+	mongoDB := mocks.NewMockNoSQLStore(ctrl)
+	mongoDB.EXPECT().DeleteOrder(gomock.Any(), orderID).Return(nil).Times(1)
+	mongoDB.EXPECT().DeleteOrder(gomock.Any(), missingID).Return(store.ErrNotFound).Times(1)
+	mongoDB.EXPECT().DeleteOrder(gomock.Any(), failingID).Return(errors.New("store error")).Times(1)
+
+	s := new(Server)
+	s.ObjectIDGenerator = utils.NewRandomObjectIDGenerator()
+	s.Log = utils.NewLogger("test", "packs-api")
+
+	tests := []struct {
+		name      string
+		id        string
+		status    int
+		errorCode string
+		errorMsg  string
+	}{
+		{"invalid id", "abc", 400, "invalid_order_id", "invalid order id"},
+		{"not found", missingID.Hex(), 404, "order_not_found", "order not found"},
+		{"store error", failingID.Hex(), 500, "internal_error", internalErrorDetail},
+		{"success", orderID.Hex(), 204, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodDelete, "/api/orders/"+tt.id, nil)
+
+			rr := httptest.NewRecorder()
+			router := mux.NewRouter()
+			router.HandleFunc("/api/orders/{id}", s.HandleDeleteOrder(mongoDB)).Methods(http.MethodDelete)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+
+			if tt.errorMsg != "" {
+				assertProblem(t, rr, tt.errorCode, tt.errorMsg)
 			}
 		})
 	}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"packs-api/internal/resources"
+	"packs-api/internal/services"
+	"packs-api/internal/tracing"
+)
+
+// validationFields maps the validation errors of the services package to the
+// request field they concern and a stable error code.
+var validationFields = []struct {
+	err   error
+	field string
+	code  string
+}{
+	{services.ErrInvalidItemsAmount, "items", "invalid_items"},
+	{services.ErrItemsTooLarge, "items", "items_too_large"},
+	{services.ErrNoPackSizes, "packSizes", "missing_pack_sizes"},
+	{services.ErrInvalidPackSize, "packSizes", "invalid_pack_size"},
+	{services.ErrDuplicatePackSize, "packSizes", "duplicate_pack_size"},
+	{services.ErrPackSizeTooLarge, "packSizes", "pack_size_too_large"},
+	{services.ErrInvalidStock, "availability", "invalid_stock"},
+	{services.ErrUnknownStockSize, "availability", "unknown_stock_size"},
+	{services.ErrUnknownObjective, "objective", "unknown_objective"},
+	{services.ErrMissingCost, "packCosts", "missing_cost"},
+	{services.ErrInvalidCost, "packCosts", "invalid_cost"},
+	{services.ErrCostTooLarge, "packCosts", "cost_too_large"},
+	{services.ErrUnknownCostSize, "packCosts", "unknown_cost_size"},
+	{services.ErrMissingMaxOverage, "maxOverage", "missing_max_overage"},
+	{services.ErrInvalidMaxOverage, "maxOverage", "invalid_max_overage"},
+}
+
+func newFieldError(err error) FieldError {
+	for _, f := range validationFields {
+		if errors.Is(err, f.err) {
+			return FieldError{Field: f.field, Code: f.code, Message: err.Error()}
+		}
+	}
+	return FieldError{Code: "invalid", Message: err.Error()}
+}
+
+// writeValidationErrors writes a validation problem listing errs.
+func writeValidationErrors(w http.ResponseWriter, r *http.Request, errs []error) {
+	msgs := make([]string, 0, len(errs))
+	fieldErrors := make([]FieldError, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+		fieldErrors = append(fieldErrors, newFieldError(err))
+	}
+
+	p := newProblem(problemValidationFailed, strings.Join(msgs, "; "))
+	p.Errors = fieldErrors
+	writeProblem(w, r, p)
+}
+
+// packingOptions validates the order request and returns the solver options
+// it asks for. On failure it writes the error response, listing every invalid
+// field, and returns false.
+func (s *Server) packingOptions(w http.ResponseWriter, r *http.Request, orderRequest *resources.OrderRequest) ([]services.Option, bool) {
+	var errs []error
+	objective, err := services.ParseObjective(orderRequest.Objective)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if err := services.ValidateOrder(orderRequest.Items, orderRequest.PackSizes, s.MaxPackSize, s.MaxItems); err != nil {
+		errs = append(errs, err)
+	}
+	if err := services.ValidateAvailability(orderRequest.PackSizes, orderRequest.Availability); err != nil {
+		errs = append(errs, err)
+	}
+	if objective != "" {
+		if err := services.ValidateObjective(objective, orderRequest.PackSizes, orderRequest.PackCosts, orderRequest.MaxOverage); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		s.logger(r.Context()).WithField("error", errors.Join(errs...).Error()).Info("invalid order request")
+		writeValidationErrors(w, r, errs)
+		return nil, false
+	}
+
+	opts := []services.Option{
+		services.WithObjective(objective),
+		services.WithAvailability(orderRequest.Availability),
+		services.WithCosts(orderRequest.PackCosts),
+	}
+	if orderRequest.MaxOverage != nil {
+		opts = append(opts, services.WithMaxOverage(*orderRequest.MaxOverage))
+	}
+
+	return opts, true
+}
+
+// packOrderRequest validates the order request and runs the packing solver on
+// it. On failure it writes the error response and returns false.
+func (s *Server) packOrderRequest(w http.ResponseWriter, r *http.Request, orderRequest *resources.OrderRequest) (*services.PackingResult, bool) {
+	opts, ok := s.packingOptions(w, r, orderRequest)
+	if !ok {
+		return nil, false
+	}
+
+	result, err := s.getPacks(r.Context(), orderRequest, opts)
+	if err != nil {
+		s.writePackingError(w, r, err)
+		return nil, false
+	}
+
+	return result, true
+}
+
+// getPacks runs services.GetPacks in a span of the request's trace.
+func (s *Server) getPacks(ctx context.Context, orderRequest *resources.OrderRequest, opts []services.Option) (*services.PackingResult, error) {
+	_, span := s.tracer().Start(ctx, "services.GetPacks", tracing.SpanTypeSolver)
+	defer span.End()
+
+	result, err := services.GetPacks(orderRequest.Items, orderRequest.PackSizes, opts...)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return result, err
+}
+
+// getTopPacks runs services.GetTopPacks in a span of the request's trace.
+func (s *Server) getTopPacks(ctx context.Context, orderRequest *resources.OrderRequest, k int, opts []services.Option) ([]*services.PackingResult, error) {
+	_, span := s.tracer().Start(ctx, "services.GetTopPacks", tracing.SpanTypeSolver)
+	defer span.End()
+
+	results, err := services.GetTopPacks(orderRequest.Items, orderRequest.PackSizes, k, opts...)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return results, err
+}
+
+// writePackingError writes the response for an error returned by the packing
+// solver.
+func (s *Server) writePackingError(w http.ResponseWriter, r *http.Request, err error) {
+	var stockErr *services.InsufficientStockError
+	switch {
+	case errors.As(err, &stockErr):
+		s.logger(r.Context()).WithField("error", err.Error()).Info("not enough stock to ship")
+		p := newProblem(problemInsufficientStock, err.Error())
+		p.Extensions = map[string]interface{}{
+			"capacity":  stockErr.Capacity,
+			"shortfall": stockErr.Shortfall,
+		}
+		writeProblem(w, r, p)
+	case errors.Is(err, services.ErrOverageNotAllowed):
+		s.logger(r.Context()).WithField("error", err.Error()).Info("no packs to ship within the allowed overage")
+		s.writeError(w, r, problemOverageNotAllowed, err.Error())
+	case errors.Is(err, services.ErrOrderTooLarge):
+		s.logger(r.Context()).WithField("error", err.Error()).Info("order too large to pack")
+		s.writeError(w, r, problemOrderTooLarge, err.Error())
+	case errors.Is(err, services.ErrNoSolution), errors.Is(err, services.ErrNoPackSizes):
+		s.logger(r.Context()).WithField("error", err.Error()).Error("no packs to ship")
+		s.writeError(w, r, problemNoSolution, err.Error())
+	default:
+		s.writeInternalError(w, r, err, "failed to pack order")
+	}
+}
+
+func newPackingSolution(result *services.PackingResult) resources.PackingSolution {
+	return resources.PackingSolution{
+		Packs:        result.Packs,
+		PackQuantity: result.PackQuantity(),
+		TotalPacks:   result.TotalPacks,
+		ItemsShipped: result.ItemsShipped,
+		Overage:      result.Overage,
+		TotalCost:    result.TotalCost,
+		Objective:    string(result.Objective),
+	}
+}
+
+// newPackLines lists packs as /api/v2 does, costed with costs.
+func newPackLines(packs []resources.Pack, costs map[int]int) []resources.PackLine {
+	lines := make([]resources.PackLine, 0, len(packs))
+	for _, pack := range packs {
+		lines = append(lines, resources.PackLine{
+			Size:     pack.Size,
+			Quantity: pack.Quantity,
+			Items:    pack.Size * pack.Quantity,
+			Cost:     costs[pack.Size] * pack.Quantity,
+		})
+	}
+	return lines
+}
+
+// orderResponse returns order in the shape of the API version serving r.
+func orderResponse(r *http.Request, order *resources.Order) interface{} {
+	if APIVersionFromContext(r.Context()) != apiVersionV2 {
+		return order
+	}
+	return resources.OrderV2{Order: *order, Packs: newPackLines(order.Packs, order.PackCosts)}
+}
+
+// ordersResponse returns orders in the shape of the API version serving r.
+func ordersResponse(r *http.Request, orders []*resources.Order) interface{} {
+	if APIVersionFromContext(r.Context()) != apiVersionV2 {
+		return orders
+	}
+	res := make([]resources.OrderV2, 0, len(orders))
+	for _, order := range orders {
+		res = append(res, resources.OrderV2{Order: *order, Packs: newPackLines(order.Packs, order.PackCosts)})
+	}
+	return res
+}
+
+// quoteResponse returns quote, costed with costs, in the shape of the API
+// version serving r.
+func quoteResponse(r *http.Request, quote resources.Quote, costs map[int]int) interface{} {
+	if APIVersionFromContext(r.Context()) != apiVersionV2 {
+		return quote
+	}
+	solutionV2 := func(solution resources.PackingSolution) resources.PackingSolutionV2 {
+		return resources.PackingSolutionV2{PackingSolution: solution, Packs: newPackLines(solution.Packs, costs)}
+	}
+	res := resources.QuoteV2{
+		Items:             quote.Items,
+		PackSizes:         quote.PackSizes,
+		PackingSolutionV2: solutionV2(quote.PackingSolution),
+	}
+	for _, alternative := range quote.Alternatives {
+		res.Alternatives = append(res.Alternatives, solutionV2(alternative))
+	}
+	return res
+}
+
+// setOrderPacking stores the request and the packing chosen for it on order.
+func setOrderPacking(order *resources.Order, orderRequest *resources.OrderRequest, result *services.PackingResult) {
+	order.Items = orderRequest.Items
+	order.PackSizes = orderRequest.PackSizes
+	order.Availability = orderRequest.Availability
+	order.PackQuantity = result.PackQuantity()
+	order.Packs = result.Packs
+	order.TotalPacks = result.TotalPacks
+	order.ItemsShipped = result.ItemsShipped
+	order.Overage = result.Overage
+	order.TotalCost = result.TotalCost
+	order.Objective = string(result.Objective)
+	order.PackCosts = orderRequest.PackCosts
+	order.MaxOverage = orderRequest.MaxOverage
+}
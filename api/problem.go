@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix turns an error code into the problem type URI.
+const problemTypePrefix = "urn:packs-api:problem:"
+
+// internalErrorDetail replaces the details of unexpected errors, which are
+// only logged.
+const internalErrorDetail = "an unexpected error occurred"
+
+// problemKind is a class of error. Its code is stable, so clients can match on
+// it instead of on the detail message.
+type problemKind struct {
+	code   string
+	status int
+	title  string
+}
+
+var (
+	problemRouteNotFound            = problemKind{"route_not_found", http.StatusNotFound, "Route not found"}
+	problemOrderNotFound            = problemKind{"order_not_found", http.StatusNotFound, "Order not found"}
+	problemInvalidOrderID           = problemKind{"invalid_order_id", http.StatusBadRequest, "Invalid order ID"}
+	problemUnsupportedMediaType     = problemKind{"unsupported_media_type", http.StatusUnsupportedMediaType, "Unsupported media type"}
+	problemMalformedBody            = problemKind{"malformed_body", http.StatusBadRequest, "Malformed request body"}
+	problemBodyTooLarge             = problemKind{"body_too_large", http.StatusRequestEntityTooLarge, "Request body too large"}
+	problemValidationFailed         = problemKind{"validation_failed", http.StatusBadRequest, "Validation failed"}
+	problemInvalidQuery             = problemKind{"invalid_query", http.StatusBadRequest, "Invalid query parameter"}
+	problemInvalidHeader            = problemKind{"invalid_header", http.StatusBadRequest, "Invalid header"}
+	problemNoSolution               = problemKind{"no_solution", http.StatusBadRequest, "No packing found"}
+	problemInsufficientStock        = problemKind{"insufficient_stock", http.StatusUnprocessableEntity, "Insufficient stock"}
+	problemOverageNotAllowed        = problemKind{"overage_not_allowed", http.StatusUnprocessableEntity, "Overage not allowed"}
+	problemOrderTooLarge            = problemKind{"order_too_large", http.StatusUnprocessableEntity, "Order too large"}
+	problemIdempotencyKeyReused     = problemKind{"idempotency_key_reused", http.StatusUnprocessableEntity, "Idempotency key reused"}
+	problemIdempotencyKeyInProgress = problemKind{"idempotency_key_in_progress", http.StatusConflict, "Request in progress"}
+	problemUnauthenticated          = problemKind{"unauthenticated", http.StatusUnauthorized, "Authentication required"}
+	problemForbidden                = problemKind{"forbidden", http.StatusForbidden, "Forbidden"}
+	problemRateLimited              = problemKind{"rate_limited", http.StatusTooManyRequests, "Too many requests"}
+	problemInternal                 = problemKind{"internal_error", http.StatusInternalServerError, "Internal server error"}
+)
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code is the stable error code the type is made from.
+	Code string `json:"code"`
+	// Errors lists the invalid fields of a request that failed validation.
+	Errors    []FieldError `json:"errors,omitempty"`
+	RequestID string       `json:"requestId,omitempty"`
+	// Extensions are written as additional members of the object.
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// FieldError is a problem with one field of the request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newProblem(kind problemKind, detail string) *Problem {
+	return &Problem{
+		Type:   problemTypePrefix + kind.code,
+		Title:  kind.title,
+		Status: kind.status,
+		Detail: detail,
+		Code:   kind.code,
+	}
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	// problem has the fields of Problem but not the method
+	type problem Problem
+	b, err := json.Marshal((*problem)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return b, err
+	}
+
+	members := make(map[string]interface{}, len(p.Extensions))
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for k, v := range fields {
+		members[k] = v
+	}
+
+	return json.Marshal(members)
+}
+
+// writeProblem writes p as the response to r, which may be nil when the
+// request is not at hand.
+func writeProblem(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if r != nil {
+		p.Instance = r.URL.Path
+		p.RequestID = RequestIDFromContext(r.Context())
+	}
+
+	res, _ := json.Marshal(p)
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(res)
+}
+
+// writeError writes a problem of the given kind.
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, kind problemKind, detail string) {
+	writeProblem(w, r, newProblem(kind, detail))
+}
+
+// writeInternalError logs err and writes a 500 that leaves out its details.
+func (s *Server) writeInternalError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	entry := s.Log
+	if r != nil {
+		entry = s.logger(r.Context())
+	}
+	entry.WithField("error", err.Error()).Error(msg)
+
+	s.writeError(w, r, problemInternal, internalErrorDetail)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertProblem checks that rr holds a problem+json response with the given
+// error code and detail, and returns its members.
+func assertProblem(t *testing.T, rr *httptest.ResponseRecorder, code, detail string) map[string]interface{} {
+	t.Helper()
+
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+
+	var res map[string]interface{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	assert.Equal(t, "urn:packs-api:problem:"+code, res["type"])
+	assert.Equal(t, code, res["code"])
+	assert.Equal(t, float64(rr.Code), res["status"])
+	assert.NotEmpty(t, res["title"])
+	assert.Equal(t, detail, res["detail"])
+
+	return res
+}
+
+func TestWriteProblem(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "/api/orders", nil)
+	req = req.WithContext(withRequestID(req.Context(), "req-1"))
+
+	p := newProblem(problemInsufficientStock, "insufficient stock")
+	p.Errors = []FieldError{{Field: "availability", Code: "invalid_stock", Message: "stock must not be negative: 2"}}
+	p.Extensions = map[string]interface{}{"capacity": 250, "status": 999}
+
+	rr := httptest.NewRecorder()
+	writeProblem(rr, req, p)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+	// Extensions cannot override the standard members
+	assert.JSONEq(t, `{
+		"type": "urn:packs-api:problem:insufficient_stock",
+		"title": "Insufficient stock",
+		"status": 422,
+		"detail": "insufficient stock",
+		"instance": "/api/orders",
+		"code": "insufficient_stock",
+		"errors": [{"field": "availability", "code": "invalid_stock", "message": "stock must not be negative: 2"}],
+		"requestId": "req-1",
+		"capacity": 250
+	}`, rr.Body.String())
+}
+
+func TestNotFoundHandler(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/api/unknown", nil)
+
+	rr := httptest.NewRecorder()
+	notFoundHandler()(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assertProblem(t, rr, "route_not_found", "page not found")
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"packs-api/internal/resources"
+)
+
+const maxAlternatives = 10
+
+// HandleCreateQuote returns the packing HandleCreateOrder would store for the
+// same request, without touching the store. The alternatives query parameter
+// adds that many of the best packings, ranked by the request's objective.
+func (s *Server) HandleCreateQuote() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		alternatives := 0
+		if v := r.URL.Query().Get("alternatives"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxAlternatives {
+				s.writeError(w, r, problemInvalidQuery, "alternatives must be a number between 1 and "+strconv.Itoa(maxAlternatives))
+				return
+			}
+			alternatives = n
+		}
+
+		orderRequest, ok := s.decodeOrderRequest(w, r)
+		if !ok {
+			return
+		}
+
+		opts, ok := s.packingOptions(w, r, orderRequest)
+		if !ok {
+			return
+		}
+
+		result, err := s.getPacks(r.Context(), orderRequest, opts)
+		if err != nil {
+			s.writePackingError(w, r, err)
+			return
+		}
+
+		quote := resources.Quote{
+			Items:           orderRequest.Items,
+			PackSizes:       orderRequest.PackSizes,
+			PackingSolution: newPackingSolution(result),
+		}
+
+		if alternatives > 0 {
+			results, err := s.getTopPacks(r.Context(), orderRequest, alternatives, opts)
+			if err != nil {
+				s.writePackingError(w, r, err)
+				return
+			}
+			for _, result := range results {
+				quote.Alternatives = append(quote.Alternatives, newPackingSolution(result))
+			}
+		}
+
+		s.writeJSONData(w, http.StatusOK, quoteResponse(r, quote, orderRequest.PackCosts))
+	}
+}
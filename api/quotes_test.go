@@ -0,0 +1,167 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"packs-api/internal/utils"
+)
+
+func TestServer_HandleCreateQuote(t *testing.T) {
+	logger := utils.NewLogger("test", "packs-api")
+
+	s := new(Server)
+	s.Log = logger
+	s.MaxPackSize = 10000
+
+	tests := []struct {
+		name        string
+		query       string
+		requestBody []byte
+		status      int
+		expected    map[string]interface{}
+		errorCode   string
+		errorMsg    string
+	}{
+		{
+			"invalid alternatives", "?alternatives=0", []byte(`{"items": 251, "packSizes": [250, 500]}`), 400,
+			nil, "invalid_query", "alternatives must be a number between 1 and 10",
+		},
+		{
+			"invalid order", "", []byte(`{"items": 0, "packSizes": [250, 500]}`), 400,
+			nil, "validation_failed", "items must be greater than zero",
+		},
+		{
+			"success", "", []byte(`{"items": 12001, "packSizes": [250, 500, 1000, 2000, 5000]}`), 200,
+			map[string]interface{}{
+				"data": map[string]interface{}{
+					"items":     float64(12001),
+					"packSizes": []interface{}{float64(250), float64(500), float64(1000), float64(2000), float64(5000)},
+					"packs": []interface{}{
+						map[string]interface{}{"size": float64(250), "quantity": float64(1)},
+						map[string]interface{}{"size": float64(2000), "quantity": float64(1)},
+						map[string]interface{}{"size": float64(5000), "quantity": float64(2)},
+					},
+					"packQuantity": map[string]interface{}{"250": float64(1), "2000": float64(1), "5000": float64(2)},
+					"totalPacks":   float64(4),
+					"itemsShipped": float64(12250),
+					"overage":      float64(249),
+					"objective":    "min_overage",
+				},
+			}, "", "",
+		},
+		{
+			"success with alternatives", "?alternatives=2", []byte(`{"items": 251, "packSizes": [250, 500]}`), 200,
+			map[string]interface{}{
+				"data": map[string]interface{}{
+					"items":        float64(251),
+					"packSizes":    []interface{}{float64(250), float64(500)},
+					"packs":        []interface{}{map[string]interface{}{"size": float64(500), "quantity": float64(1)}},
+					"packQuantity": map[string]interface{}{"500": float64(1)},
+					"totalPacks":   float64(1),
+					"itemsShipped": float64(500),
+					"overage":      float64(249),
+					"objective":    "min_overage",
+					"alternatives": []interface{}{
+						map[string]interface{}{
+							"packs":        []interface{}{map[string]interface{}{"size": float64(500), "quantity": float64(1)}},
+							"packQuantity": map[string]interface{}{"500": float64(1)},
+							"totalPacks":   float64(1),
+							"itemsShipped": float64(500),
+							"overage":      float64(249),
+							"objective":    "min_overage",
+						},
+						map[string]interface{}{
+							"packs":        []interface{}{map[string]interface{}{"size": float64(250), "quantity": float64(2)}},
+							"packQuantity": map[string]interface{}{"250": float64(2)},
+							"totalPacks":   float64(2),
+							"itemsShipped": float64(500),
+							"overage":      float64(249),
+							"objective":    "min_overage",
+						},
+					},
+				},
+			}, "", "",
+		},
+		{
+			"unsupported media type", "", nil, 415,
+			nil, "unsupported_media_type", "Content-Type must be application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/api/quotes"+tt.query, bytes.NewReader(tt.requestBody))
+			if tt.requestBody != nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+
+			rr := httptest.NewRecorder()
+			router := mux.NewRouter()
+			router.HandleFunc("/api/quotes", s.HandleCreateQuote()).Methods(http.MethodPost)
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+
+			if tt.errorCode != "" {
+				assertProblem(t, rr, tt.errorCode, tt.errorMsg)
+				return
+			}
+
+			var res map[string]interface{}
+			err := json.Unmarshal(rr.Body.Bytes(), &res)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, res)
+		})
+	}
+}
+
+func TestServer_HandleCreateQuote_FieldErrors(t *testing.T) {
+	s := new(Server)
+	s.Log = utils.NewLogger("test", "packs-api")
+	s.MaxPackSize = 10000
+
+	body := []byte(`{"items": 0, "packSizes": [250, 500], "objective": "cheapest"}`)
+	req, _ := http.NewRequest(http.MethodPost, "/api/quotes", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/api/quotes", s.HandleCreateQuote()).Methods(http.MethodPost)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	res := assertProblem(t, rr, "validation_failed", `unknown objective: "cheapest"; items must be greater than zero`)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "objective", "code": "unknown_objective", "message": `unknown objective: "cheapest"`},
+		map[string]interface{}{"field": "items", "code": "invalid_items", "message": "items must be greater than zero"},
+	}, res["errors"])
+}
+
+func TestServer_HandleCreateQuote_ItemsTooLarge(t *testing.T) {
+	s := new(Server)
+	s.Log = utils.NewLogger("test", "packs-api")
+	s.MaxPackSize = 10000
+	s.MaxItems = 1000000
+
+	body := []byte(`{"items": 9223372036854775797, "packSizes": [250, 500]}`)
+	req, _ := http.NewRequest(http.MethodPost, "/api/quotes", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/api/quotes", s.HandleCreateQuote()).Methods(http.MethodPost)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	res := assertProblem(t, rr, "validation_failed", "items exceeds the maximum allowed of 1000000: 9223372036854775797")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "items", "code": "items_too_large", "message": "items exceeds the maximum allowed of 1000000: 9223372036854775797"},
+	}, res["errors"])
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"packs-api/internal/config"
+)
+
+// bucketSweepInterval is how often full buckets are dropped, so clients that
+// went away do not keep their bucket in memory.
+const bucketSweepInterval = time.Minute
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimiter keeps a token bucket per client. Each bucket holds up to burst
+// tokens and refills at rate tokens per second.
+type rateLimiter struct {
+	rate  float64
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// rateLimitResult describes a client's bucket after a request.
+type rateLimitResult struct {
+	allowed   bool
+	remaining int
+	// reset is the time until the bucket is full again
+	reset time.Duration
+	// retryAfter is the time until the next token, zero when allowed
+	retryAfter time.Duration
+}
+
+// newRateLimiter returns nil for a disabled limit.
+func newRateLimiter(limit config.RateLimit) *rateLimiter {
+	if limit.Rate <= 0 || limit.Burst < 1 {
+		return nil
+	}
+
+	return &rateLimiter{
+		rate:    limit.Rate,
+		burst:   limit.Burst,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// take removes a token from the client's bucket if there is one.
+func (l *rateLimiter) take(key string, now time.Time) rateLimitResult {
+	return l.check(key, now, true)
+}
+
+// peek reports what take would, without removing the token.
+func (l *rateLimiter) peek(key string, now time.Time) rateLimitResult {
+	return l.check(key, now, false)
+}
+
+// check refills the client's bucket and, if consume is set, removes a token
+// from it when there is one.
+func (l *rateLimiter) check(key string, now time.Time, consume bool) rateLimitResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= bucketSweepInterval {
+		for k, b := range l.buckets {
+			if l.refill(b, now) >= float64(l.burst) {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.burst), updated: now}
+		l.buckets[key] = b
+	}
+
+	var res rateLimitResult
+	b.tokens = l.refill(b, now)
+	b.updated = now
+	if b.tokens >= 1 {
+		if consume {
+			b.tokens--
+		}
+		res.allowed = true
+	} else {
+		res.retryAfter = l.duration(1 - b.tokens)
+	}
+	res.remaining = int(b.tokens)
+	res.reset = l.duration(float64(l.burst) - b.tokens)
+
+	return res
+}
+
+// refill returns the bucket's tokens at now.
+func (l *rateLimiter) refill(b *tokenBucket, now time.Time) float64 {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed <= 0 {
+		return b.tokens
+	}
+	return math.Min(float64(l.burst), b.tokens+elapsed*l.rate)
+}
+
+// duration returns the time it takes to refill the given tokens.
+func (l *rateLimiter) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// rateLimit limits requests per caller, or per client IP for unauthenticated
+// requests. GET, HEAD and OPTIONS requests take from the read limiter and the
+// rest from the write limiter; a nil limiter lets requests through. Paths at
+// or below exemptPath are not limited.
+func (s *Server) rateLimit(read, write *rateLimiter, exemptPath string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limiter := requestLimiter(r, read, write, exemptPath)
+			if limiter == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res := limiter.take(rateLimitKey(r), s.Time.Now())
+			if !s.checkRateLimit(w, r, limiter, res) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limitFailedAuth runs before authentication and limits the requests that
+// fail it per client IP, so retried bad credentials are cut off before the
+// API key lookup. Only 401s take a token, from the same bucket rateLimit uses
+// for the IP, and a client whose bucket is empty gets a 429 instead of being
+// authenticated.
+func (s *Server) limitFailedAuth(read, write *rateLimiter, exemptPath string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limiter := requestLimiter(r, read, write, exemptPath)
+			if limiter == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := rateLimitKey(r)
+			if res := limiter.peek(key, s.Time.Now()); !res.allowed {
+				s.checkRateLimit(w, r, limiter, res)
+				return
+			}
+
+			lrw := newLogResponseWriter(w, nil)
+			next.ServeHTTP(lrw, r)
+			if lrw.statusCode == http.StatusUnauthorized {
+				limiter.take(key, s.Time.Now())
+			}
+		})
+	}
+}
+
+// requestLimiter returns the limiter of the request's kind: read for GET,
+// HEAD and OPTIONS requests and write for the rest. It returns nil for paths
+// at or below exemptPath.
+func requestLimiter(r *http.Request, read, write *rateLimiter, exemptPath string) *rateLimiter {
+	if r.URL.Path == exemptPath || strings.HasPrefix(r.URL.Path, exemptPath+"/") {
+		return nil
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return read
+	default:
+		return write
+	}
+}
+
+// checkRateLimit writes the rate limit headers for res, and the 429 response
+// when the request is not allowed. It reports whether the request is allowed.
+func (s *Server) checkRateLimit(w http.ResponseWriter, r *http.Request, limiter *rateLimiter, res rateLimitResult) bool {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(limiter.burst))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.reset)))
+	if !res.allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.retryAfter)))
+		s.writeError(w, r, problemRateLimited, "rate limit exceeded")
+		return false
+	}
+
+	return true
+}
+
+// rateLimitKey identifies the client of a request by its caller, falling back
+// to the remote IP.
+func rateLimitKey(r *http.Request) string {
+	if caller := CallerFromContext(r.Context()); caller != nil {
+		return "caller:" + caller.ID
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
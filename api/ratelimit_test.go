@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"packs-api/internal/config"
+	"packs-api/internal/resources"
+	"packs-api/internal/store"
+	"packs-api/internal/utils"
+	"packs-api/mocks"
+)
+
+func TestRateLimiter_Take(t *testing.T) {
+	l := newRateLimiter(config.RateLimit{Rate: 2, Burst: 3})
+	start := time.Date(2023, 11, 04, 20, 34, 58, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		key     string
+		elapsed time.Duration
+		want    rateLimitResult
+	}{
+		{"first", "a", 0, rateLimitResult{allowed: true, remaining: 2, reset: 500 * time.Millisecond}},
+		{"second", "a", 0, rateLimitResult{allowed: true, remaining: 1, reset: time.Second}},
+		{"third", "a", 0, rateLimitResult{allowed: true, remaining: 0, reset: 1500 * time.Millisecond}},
+		{"empty", "a", 0, rateLimitResult{allowed: false, remaining: 0, reset: 1500 * time.Millisecond, retryAfter: 500 * time.Millisecond}},
+		{"other client", "b", 0, rateLimitResult{allowed: true, remaining: 2, reset: 500 * time.Millisecond}},
+		{"refilled one", "a", 500 * time.Millisecond, rateLimitResult{allowed: true, remaining: 0, reset: 1500 * time.Millisecond}},
+		{"refilled to burst", "a", time.Hour, rateLimitResult{allowed: true, remaining: 2, reset: 500 * time.Millisecond}},
+	}
+
+	now := start
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.elapsed)
+			assert.Equal(t, tt.want, l.take(tt.key, now))
+		})
+	}
+
+	// The sweep on the last take dropped the full bucket of b
+	assert.Len(t, l.buckets, 1)
+}
+
+func TestNewRateLimiter_Disabled(t *testing.T) {
+	assert.Nil(t, newRateLimiter(config.RateLimit{}))
+	assert.Nil(t, newRateLimiter(config.RateLimit{Rate: 1}))
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	freezedTime := mocks.NewMockTime(ctrl)
+	freezedTime.EXPECT().Now().Return(time.Date(2023, 11, 04, 20, 34, 58, 651387237, time.UTC)).AnyTimes()
+
+	s := new(Server)
+	s.Time = freezedTime
+	s.Log = utils.NewLogger("test", "packs-api")
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router := mux.NewRouter()
+	router.Use(s.rateLimit(
+		newRateLimiter(config.RateLimit{Rate: 1, Burst: 2}),
+		newRateLimiter(config.RateLimit{Rate: 0.5, Burst: 1}),
+		"/api/status",
+	))
+	router.HandleFunc("/api/status", ok)
+	router.HandleFunc("/api/orders", ok)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		remoteAddr string
+		caller     *Caller
+		status     int
+		remaining  string
+		retryAfter string
+	}{
+		{"write", http.MethodPost, "/api/orders", "10.0.0.1:1234", nil, 200, "0", ""},
+		{"write over limit", http.MethodPost, "/api/orders", "10.0.0.1:1234", nil, 429, "0", "2"},
+		{"reads have their own limit", http.MethodGet, "/api/orders", "10.0.0.1:1234", nil, 200, "1", ""},
+		{"read", http.MethodGet, "/api/orders", "10.0.0.1:5678", nil, 200, "0", ""},
+		{"read over limit", http.MethodGet, "/api/orders", "10.0.0.1:1234", nil, 429, "0", "1"},
+		{"other client", http.MethodGet, "/api/orders", "10.0.0.2:1234", nil, 200, "1", ""},
+		{"callers are keyed by ID", http.MethodPost, "/api/orders", "10.0.0.1:1234", &Caller{ID: "ann"}, 200, "0", ""},
+		{"status is exempt", http.MethodGet, "/api/status", "10.0.0.1:1234", nil, 200, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.caller != nil {
+				ctx = withCaller(ctx, tt.caller)
+			}
+			req, _ := http.NewRequestWithContext(ctx, tt.method, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.remaining, rr.Header().Get("RateLimit-Remaining"))
+			assert.Equal(t, tt.retryAfter, rr.Header().Get("Retry-After"))
+
+			if tt.status == http.StatusTooManyRequests {
+				assertProblem(t, rr, "rate_limited", "rate limit exceeded")
+			}
+		})
+	}
+}
+
+func TestServer_LimitFailedAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	freezedTime := mocks.NewMockTime(ctrl)
+	freezedTime.EXPECT().Now().Return(time.Date(2023, 11, 04, 20, 34, 58, 651387237, time.UTC)).AnyTimes()
+
+	apiKey := &resources.APIKey{ID: primitive.NewObjectID(), Name: "warehouse", Role: "operator"}
+
+	// The lookups past the limit never reach the store
+	mongoDB := mocks.NewMockNoSQLStore(ctrl)
+	mongoDB.EXPECT().GetAPIKey(gomock.Any(), utils.HashAPIKey("secret")).Return(apiKey, nil).Times(2)
+	mongoDB.EXPECT().GetAPIKey(gomock.Any(), utils.HashAPIKey("unknown")).Return(nil, store.ErrNotFound).Times(2)
+
+	s := new(Server)
+	s.Time = freezedTime
+	s.Log = utils.NewLogger("test", "packs-api")
+
+	read := newRateLimiter(config.RateLimit{Rate: 1, Burst: 2})
+	write := newRateLimiter(config.RateLimit{Rate: 1, Burst: 2})
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router := mux.NewRouter()
+	router.Use(s.limitFailedAuth(read, write, "/api/status"))
+	router.Use(s.authenticate(mongoDB, nil, "/api/status"))
+	router.Use(s.rateLimit(read, write, "/api/status"))
+	router.HandleFunc("/api/status", ok)
+	router.HandleFunc("/api/orders", ok)
+
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		key        string
+		status     int
+		retryAfter string
+	}{
+		{"valid key", "/api/orders", "10.0.0.1:1234", "secret", 200, ""},
+		{"unknown key", "/api/orders", "10.0.0.1:1234", "unknown", 401, ""},
+		{"valid keys leave the IP's tokens", "/api/orders", "10.0.0.1:5678", "", 401, ""},
+		{"unknown key over limit", "/api/orders", "10.0.0.1:1234", "unknown", 429, "1"},
+		{"valid key over the IP's limit", "/api/orders", "10.0.0.1:1234", "secret", 429, "1"},
+		{"other client", "/api/orders", "10.0.0.2:1234", "unknown", 401, ""},
+		{"other client with a valid key", "/api/orders", "10.0.0.2:1234", "secret", 200, ""},
+		{"status is exempt", "/api/status", "10.0.0.1:1234", "", 200, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.key != "" {
+				req.Header.Set("X-API-KEY", tt.key)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.retryAfter, rr.Header().Get("Retry-After"))
+
+			if tt.status == http.StatusTooManyRequests {
+				assertProblem(t, rr, "rate_limited", "rate limit exceeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/sirupsen/logrus"
+
+	"packs-api/internal/metrics"
+)
+
+// recoverPanics turns a panic in any middleware or handler below it into a
+// 500 problem, after logging it with its stack, reporting it to the tracer
+// and counting it in metrics.Panics. When the response was already started
+// the panic is only reported. http.ErrAbortHandler is passed on, as it is
+// how a handler aborts a response on purpose.
+func (s *Server) recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lrw := newLogResponseWriter(w, nil)
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			route := routeTemplate(r)
+			s.tracer().RecordPanic(r.Context(), v)
+			metrics.Panics.WithLabelValues(route).Inc()
+			s.logger(r.Context()).WithFields(logrus.Fields{
+				"panic":  fmt.Sprint(v),
+				"stack":  string(debug.Stack()),
+				"route":  route,
+				"method": r.Method,
+				"uri":    r.RequestURI,
+			}).Error("recovered from a panic")
+
+			if lrw.statusCode == 0 {
+				s.writeError(lrw, r, problemInternal, internalErrorDetail)
+			}
+		}()
+
+		next.ServeHTTP(lrw, r)
+	})
+}
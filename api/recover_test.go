@@ -0,0 +1,88 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"packs-api/internal/metrics"
+	"packs-api/internal/utils"
+)
+
+func TestServer_RecoverPanics(t *testing.T) {
+	var out bytes.Buffer
+	logger := utils.NewLogger("test", "packs-api")
+	logger.Logger.SetOutput(&out)
+
+	s := new(Server)
+	s.Log = logger
+
+	router := mux.NewRouter()
+	router.Use(s.recoverPanics)
+	router.HandleFunc("/api/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "started" {
+			w.WriteHeader(http.StatusOK)
+		}
+		var order map[string]int
+		order["items"] = 1
+	})
+	h := s.assignRequestID(router)
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/api/orders/1", http.StatusInternalServerError},
+		// The status is already sent, so the panic is only reported
+		{"/api/orders/started", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			out.Reset()
+			panics := testutil.ToFloat64(metrics.Panics.WithLabelValues("/api/orders/{id}"))
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(requestIDHeader, "req-1")
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.status == http.StatusInternalServerError {
+				res := assertProblem(t, rr, "internal_error", internalErrorDetail)
+				assert.Equal(t, "req-1", res["requestId"])
+			}
+
+			var entry map[string]interface{}
+			assert.Nil(t, json.Unmarshal(out.Bytes(), &entry))
+			assert.Equal(t, "recovered from a panic", entry["msg"])
+			assert.Equal(t, "assignment to entry in nil map", entry["panic"])
+			assert.Equal(t, "/api/orders/{id}", entry["route"])
+			assert.Equal(t, "req-1", entry["requestId"])
+			assert.True(t, strings.Contains(entry["stack"].(string), "recover_test.go"))
+
+			assert.Equal(t, panics+1, testutil.ToFloat64(metrics.Panics.WithLabelValues("/api/orders/{id}")))
+		})
+	}
+}
+
+func TestServer_RecoverPanics_AbortHandler(t *testing.T) {
+	s := new(Server)
+	s.Log = utils.NewLogger("test", "packs-api")
+
+	h := s.recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/orders", nil)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// withRequestID returns a copy of ctx that carries the request ID.
+func withRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// RequestIDFromContext returns the ID assigned to the request, or an empty
+// string outside of a request.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// requestIDHeader carries the request ID in both directions.
+const requestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// assignRequestID gives every request an ID, so that error responses can be
+// matched with the logs. The ID in the X-Request-ID header is kept when it
+// is valid, so the caller's logs can be matched too, and a new one is made
+// otherwise. Either way it is echoed in the response header.
+func (s *Server) assignRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(withRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID reports whether id is 1 to 128 printable ASCII characters
+// without spaces, which is safe to log and echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// logger returns s.Log with the ID and trace of the request handling ctx, so
+// that every line logged for one request can be found together.
+func (s *Server) logger(ctx context.Context) *logrus.Entry {
+	entry := s.Log
+	if id := RequestIDFromContext(ctx); id != "" {
+		entry = entry.WithField("requestId", id)
+	}
+	return entry.WithFields(s.tracer().LogFields(ctx))
+}
+
+// newRequestID returns 16 random bytes in hex.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"packs-api/internal/config"
+	"packs-api/internal/utils"
+)
+
+func TestServer_AssignRequestID(t *testing.T) {
+	s := new(Server)
+	s.Log = utils.NewLogger("test", "packs-api")
+
+	tests := []struct {
+		name   string
+		header string
+		kept   bool
+	}{
+		{"missing", "", false},
+		{"uuid", "0b6c8a5e-3f2d-4c1a-9e7b-2d4f6a8c0e1b", true},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"spaces", "my request", false},
+		{"not ascii", "réquest", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			h := s.assignRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = RequestIDFromContext(r.Context())
+			}))
+
+			req, _ := http.NewRequest(http.MethodGet, "/api/orders", nil)
+			if tt.header != "" {
+				req.Header.Set(requestIDHeader, tt.header)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			assert.Equal(t, seen, rr.Header().Get(requestIDHeader))
+			if tt.kept {
+				assert.Equal(t, tt.header, seen)
+			} else {
+				assert.Len(t, seen, 32)
+			}
+		})
+	}
+}
+
+func TestServer_Logger_RequestID(t *testing.T) {
+	var out bytes.Buffer
+	logger := utils.NewLogger("test", "packs-api")
+	logger.Logger.SetOutput(&out)
+
+	cfg := &config.Config{PathPrefix: "/api", APIVersions: []config.APIVersion{{Name: "v1"}}}
+	s := NewServer(cfg, logger)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/status", nil)
+	req.Header.Set(requestIDHeader, "req-1")
+	s.srv.Handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// The handler's line and the access log line
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	for _, line := range lines {
+		var entry map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, "req-1", entry["requestId"], line)
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"mime"
 	"net/http"
-	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,59 +13,194 @@ import (
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
-	"go.elastic.co/apm/module/apmgorilla"
-	"go.elastic.co/apm/module/apmlogrus"
 
+	"packs-api/internal/auth"
 	"packs-api/internal/config"
+	"packs-api/internal/store"
+	"packs-api/internal/tracing"
 	"packs-api/internal/utils"
 )
 
+type contextKey int
+
+const (
+	callerContextKey contextKey = iota
+	requestIDContextKey
+	apiVersionContextKey
+)
+
 func notFoundHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		writeJSONError(w, http.StatusNotFound, "page not found")
+		writeProblem(w, r, newProblem(problemRouteNotFound, "page not found"))
 	}
 }
 
 type Server struct {
 	srv                    *http.Server
+	router                 *mux.Router
 	skipHealthCheckLogging bool
+	accessLog              config.AccessLog
 	ObjectIDGenerator      utils.ObjectIDGenerator
 	Time                   utils.Time
 	Log                    *logrus.Entry
 	Cache                  addcache.Cache
+	MaxPackSize            int
+	MaxItems               int
+	IdempotencyTTL         time.Duration
+	MaxBodyBytes           int64
+	HealthCheckTimeout     time.Duration
+	// Tracer records the spans of requests and the solver, nil to record
+	// none.
+	Tracer tracing.Tracer
+	// authEnabled makes routes check the caller's role, see requireRole.
+	authEnabled bool
+	// statusPath is the path of the status routes, which are not
+	// authenticated or rate limited.
+	statusPath string
 }
 
 func NewServer(cfg *config.Config, logger *logrus.Entry) *Server {
 	s := new(Server)
 	router := mux.NewRouter()
 	router.NotFoundHandler = notFoundHandler()
-	apmgorilla.Instrument(router)
-
-	router.Use(s.writeSecurityHeaders, s.loggingHandlerWrapper)
+	s.Tracer = cfg.Tracer
+	s.tracer().Instrument(router)
 
-	h := handlers.CORS(
+	corsOptions := []handlers.CORSOption{
 		handlers.AllowedOrigins(cfg.AllowedOrigins),
 		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
-		handlers.AllowedHeaders([]string{"Accept", "Content-Type", "Content-Length", "access-control-allow-origin", "Accept-Encoding", "X-CSRF-Token", "Authorization", "X-API-KEY"}),
-	)(router)
+		handlers.AllowedHeaders([]string{"Accept", "Content-Type", "Content-Length", "access-control-allow-origin", "Accept-Encoding", "X-CSRF-Token", "Authorization", "X-API-KEY", "Idempotency-Key", "X-Request-ID"}),
+		handlers.ExposedHeaders([]string{"Location", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "Idempotent-Replayed", "Deprecation", "Sunset", "X-Request-ID"}),
+	}
+	if cfg.CORSMaxAge > 0 {
+		corsOptions = append(corsOptions, handlers.MaxAge(int(cfg.CORSMaxAge.Seconds())))
+	}
+	h := handlers.CORS(corsOptions...)(s.assignRequestID(router))
+	if cfg.MetricsPath != "" {
+		h = serveMetrics(cfg.MetricsPath, h)
+	}
 
 	randomObjectIDGenerator := utils.NewRandomObjectIDGenerator()
 	realTime := utils.NewRealTime()
 
-	s.srv = &http.Server{Addr: cfg.Addr, Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	s.srv = &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           h,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+	s.router = router
 	s.skipHealthCheckLogging = cfg.SkipHealthCheckLogging
+	s.accessLog = cfg.AccessLog
 	s.Log = logger
 	s.ObjectIDGenerator = randomObjectIDGenerator
 	s.Time = realTime
+	s.MaxPackSize = cfg.MaxPackSize
+	s.MaxItems = cfg.MaxItems
+	s.IdempotencyTTL = cfg.IdempotencyTTL
+	s.MaxBodyBytes = cfg.MaxBodyBytes
+	s.HealthCheckTimeout = cfg.HealthCheckTimeout
 
 	pathPrefix := cfg.PathPrefix
+	s.statusPath = pathPrefix + "/status"
+
+	// Authentication runs before logging so the access log has the caller,
+	// and rate limiting after both so it can key on the caller and its 429s
+	// are logged. Failed authentication is limited per IP before it, so
+	// retried bad credentials stop reaching the store. Requests are measured
+	// first, so rejected ones count too, and panics anywhere below are
+	// recovered
+	readLimiter, writeLimiter := newRateLimiter(cfg.ReadRateLimit), newRateLimiter(cfg.WriteRateLimit)
+	router.Use(s.measureRequests)
+	router.Use(s.recoverPanics)
+	router.Use(s.writeSecurityHeaders)
+	var apiKeys store.NoSQLStore
+	if cfg.APIKeyAuth {
+		apiKeys = cfg.MongoDB
+	}
+	if apiKeys != nil || cfg.JWTVerifier != nil {
+		s.authEnabled = true
+		router.Use(s.limitFailedAuth(readLimiter, writeLimiter, s.statusPath))
+		router.Use(s.authenticate(apiKeys, cfg.JWTVerifier, s.statusPath))
+	}
+	router.Use(s.loggingHandlerWrapper)
+	router.Use(s.rateLimit(readLimiter, writeLimiter, s.statusPath))
+
+	router.HandleFunc(s.statusPath, s.HandleStatus()).Methods(http.MethodGet)
+	router.HandleFunc(s.statusPath+"/live", s.HandleLive()).Methods(http.MethodGet)
+	router.HandleFunc(s.statusPath+"/ready", s.HandleReady(cfg.MongoDB)).Methods(http.MethodGet)
+
+	// The unversioned routes are registered last, so they do not shadow
+	// the versioned ones
+	for _, version := range cfg.APIVersions {
+		versionPrefix := pathPrefix + "/" + version.Name
+		s.handleAPIVersion(router.PathPrefix(versionPrefix).Subrouter(), pathPrefix, versionPrefix, version, cfg.MongoDB)
+	}
+	if len(cfg.APIVersions) > 0 {
+		s.handleAPIVersion(router.PathPrefix(pathPrefix).Subrouter(), pathPrefix, pathPrefix, cfg.APIVersions[0], cfg.MongoDB)
+	}
 
-	router.HandleFunc(pathPrefix+"/status", s.Recover(s.HandleStatus(), true)).Methods(http.MethodGet)
+	return s
+}
 
-	router.HandleFunc(pathPrefix+"/orders", s.HandleCreateOrder(cfg.MongoDB)).Methods(http.MethodPost)
-	router.HandleFunc(pathPrefix+"/orders", s.HandleGetAllOrders(cfg.MongoDB)).Methods(http.MethodGet)
+// handleAPIVersion registers the routes of version on router, which serves
+// the paths below versionPrefix. The handlers read the version from the
+// request context, see APIVersionFromContext.
+func (s *Server) handleAPIVersion(router *mux.Router, pathPrefix, versionPrefix string, version config.APIVersion, mongoDB store.NoSQLStore) {
+	router.Use(s.writeVersionHeaders(version))
 
-	return s
+	router.HandleFunc("/openapi.json", s.HandleOpenAPI(pathPrefix, versionPrefix, version.Name)).Methods(http.MethodGet)
+
+	router.HandleFunc("/orders", s.requireRole(auth.RoleOperator, s.idempotent(mongoDB, s.HandleCreateOrder(mongoDB)))).Methods(http.MethodPost)
+	router.HandleFunc("/orders", s.requireRole(auth.RoleViewer, s.HandleGetAllOrders(mongoDB))).Methods(http.MethodGet)
+	router.HandleFunc("/orders/{id}", s.requireRole(auth.RoleViewer, s.HandleGetOrder(mongoDB))).Methods(http.MethodGet)
+	router.HandleFunc("/orders/{id}", s.requireRole(auth.RoleOperator, s.HandleUpdateOrder(mongoDB))).Methods(http.MethodPatch)
+	router.HandleFunc("/orders/{id}", s.requireRole(auth.RoleAdmin, s.HandleDeleteOrder(mongoDB))).Methods(http.MethodDelete)
+
+	router.HandleFunc("/quotes", s.requireRole(auth.RoleViewer, s.HandleCreateQuote())).Methods(http.MethodPost)
+}
+
+// apiVersionV2 is the API version whose packings list PackLines.
+const apiVersionV2 = "v2"
+
+// withAPIVersion returns a copy of ctx that carries the API version.
+func withAPIVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, apiVersionContextKey, version)
+}
+
+// APIVersionFromContext returns the name of the API version that serves the
+// request, or an empty string for unversioned routes.
+func APIVersionFromContext(ctx context.Context) string {
+	version, _ := ctx.Value(apiVersionContextKey).(string)
+	return version
+}
+
+// writeVersionHeaders announces the deprecation and sunset of version with
+// the Deprecation (RFC 9745) and Sunset (RFC 8594) headers, and stores the
+// version in the request context.
+func (s *Server) writeVersionHeaders(version config.APIVersion) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !version.Deprecation.IsZero() {
+				w.Header().Set("Deprecation", "@"+strconv.FormatInt(version.Deprecation.Unix(), 10))
+			}
+			if !version.Sunset.IsZero() {
+				w.Header().Set("Sunset", version.Sunset.UTC().Format(http.TimeFormat))
+			}
+
+			next.ServeHTTP(w, r.WithContext(withAPIVersion(r.Context(), version.Name)))
+		})
+	}
+}
+
+// tracer returns s.Tracer, or tracing.Noop when it is nil.
+func (s *Server) tracer() tracing.Tracer {
+	if s.Tracer == nil {
+		return tracing.Noop
+	}
+	return s.Tracer
 }
 
 func (s *Server) HasContentType(r *http.Request, mimetype string) bool {
@@ -113,97 +248,21 @@ func (s *Server) writeSecurityHeaders(wh http.Handler) http.Handler {
 	})
 }
 
-func (s *Server) WriteJSONError(w http.ResponseWriter, code int, msg string) {
-	writeJSONError(w, code, msg)
+// writeJSONData writes v in the {"data": ...} envelope.
+func (s *Server) writeJSONData(w http.ResponseWriter, c int, v interface{}) {
+	s.writeJSON(w, c, map[string]interface{}{
+		"data": v,
+	})
 }
 
-func (s *Server) Recover(next http.HandlerFunc, printstack bool) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-
-		defer func() {
-			if err := recover(); err != nil {
-				if printstack {
-					debug.PrintStack()
-				}
-
-				s.WriteJSONError(w, http.StatusInternalServerError, "internal http error")
-			}
-		}()
-
-		next(w, r)
+func (s *Server) writeJSON(w http.ResponseWriter, c int, body interface{}) {
+	jsonResponse, err := json.Marshal(body)
+	if err != nil {
+		s.writeInternalError(w, nil, err, "invalid response body")
+		return
 	}
-}
 
-func writeJSONError(w http.ResponseWriter, c int, msg string) {
-	errObject := map[string]interface{}{"error": true, "code": c, "message": msg}
-	res, _ := json.Marshal(errObject)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(c)
-	_, _ = w.Write(res)
-}
-
-type logResponseWriter struct {
-	http.ResponseWriter
-	statusCode int
-	body       []byte
-}
-
-func (lrw *logResponseWriter) WriteHeader(code int) {
-	lrw.statusCode = code
-	lrw.ResponseWriter.WriteHeader(code)
-}
-
-func (lrw *logResponseWriter) Write(body []byte) (int, error) {
-	lrw.body = body
-	return lrw.ResponseWriter.Write(body)
-}
-
-func newLogResponseWriter(w http.ResponseWriter) *logResponseWriter {
-	return &logResponseWriter{ResponseWriter: w}
-}
-
-func (s *Server) HandleStatus() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		s.Log.Info("Status API called")
-		w.WriteHeader(http.StatusOK)
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write([]byte(`{"status": "ok"}`))
-	}
-}
-
-func (s *Server) loggingHandlerWrapper(wrappedHandler http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := s.Time.Now()
-		lrw := newLogResponseWriter(w)
-		wrappedHandler.ServeHTTP(lrw, r)
-		duration := s.Time.Now().Sub(start)
-
-		if s.skipHealthCheckLogging && strings.HasSuffix(r.URL.Path, "/packs-api/status/") {
-			return
-		}
-
-		var resp interface{}
-		//nolint:typecheck
-		lrwContentType := lrw.Header().Get("Content-Type")
-
-		if compareContentTypes(lrwContentType, "application/json") {
-			_ = json.Unmarshal(lrw.body, &resp)
-		}
-
-		fields := logrus.Fields{
-			"duration":   duration.String(),
-			"statusCode": lrw.statusCode,
-			"uri":        r.RequestURI,
-			"method":     r.Method,
-		}
-
-		if resp != nil {
-			fields["response"] = resp
-		}
-
-		s.Log.
-			WithFields(apmlogrus.TraceContext(r.Context())).
-			WithFields(fields).
-			Info("Request handling took: ", duration)
-	})
+	_, _ = w.Write(jsonResponse)
 }
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"packs-api/internal/config"
+	"packs-api/internal/utils"
+)
+
+func TestNewServer_APIVersions(t *testing.T) {
+	cfg := &config.Config{
+		PathPrefix: "/api",
+		APIVersions: []config.APIVersion{
+			{
+				Name:        "v1",
+				Deprecation: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				Sunset:      time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+			},
+			{Name: "v2"},
+		},
+	}
+	s := NewServer(cfg, utils.NewLogger("test", "packs-api"))
+
+	tests := []struct {
+		path       string
+		status     int
+		server     string
+		deprecated bool
+	}{
+		{"/api/openapi.json", 200, "/api", true},
+		{"/api/v1/openapi.json", 200, "/api/v1", true},
+		{"/api/v2/openapi.json", 200, "/api/v2", false},
+		{"/api/v3/openapi.json", 404, "", false},
+		{"/api/status", 200, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			rr := httptest.NewRecorder()
+			s.srv.Handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.server != "" {
+				assert.Contains(t, rr.Body.String(), `"servers":[{"url":"`+tt.server+`"}]`)
+			}
+			if tt.deprecated {
+				assert.Equal(t, "@1767225600", rr.Header().Get("Deprecation"))
+				assert.Equal(t, "Wed, 01 Jul 2026 00:00:00 GMT", rr.Header().Get("Sunset"))
+			} else {
+				assert.Empty(t, rr.Header().Get("Deprecation"))
+				assert.Empty(t, rr.Header().Get("Sunset"))
+			}
+		})
+	}
+}
+
+func TestNewServer_APIVersionResponses(t *testing.T) {
+	cfg := &config.Config{PathPrefix: "/api", APIVersions: []config.APIVersion{{Name: "v1"}, {Name: "v2"}}}
+	s := NewServer(cfg, utils.NewLogger("test", "packs-api"))
+
+	tests := []struct {
+		path  string
+		packs string
+	}{
+		{"/api/quotes", `[{"size":250,"quantity":1},{"size":500,"quantity":1}]`},
+		{"/api/v1/quotes", `[{"size":250,"quantity":1},{"size":500,"quantity":1}]`},
+		{"/api/v2/quotes", `[{"size":250,"quantity":1,"items":250,"cost":3},{"size":500,"quantity":1,"items":500,"cost":5}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			body := `{"items": 501, "packSizes": [250, 500], "objective": "min_cost", "packCosts": {"250": 3, "500": 5}}`
+			req, _ := http.NewRequest(http.MethodPost, tt.path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			s.srv.Handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			var res struct {
+				Data struct {
+					Packs     json.RawMessage `json:"packs"`
+					TotalCost int             `json:"totalCost"`
+				} `json:"data"`
+			}
+			assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &res))
+			assert.Equal(t, tt.packs, string(res.Data.Packs))
+			assert.Equal(t, 8, res.Data.TotalCost)
+		})
+	}
+}
+
+func TestServer_LoggingHandlerWrapper_SkipHealthCheckLogging(t *testing.T) {
+	var out bytes.Buffer
+	logger := utils.NewLogger("test", "packs-api")
+	logger.Logger.SetOutput(&out)
+
+	cfg := &config.Config{PathPrefix: "/api", SkipHealthCheckLogging: true, APIVersions: []config.APIVersion{{Name: "v1"}}}
+	s := NewServer(cfg, logger)
+
+	tests := []struct {
+		path   string
+		logged bool
+	}{
+		{"/api/status", false},
+		{"/api/status/live", false},
+		{"/api/v1/openapi.json", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			out.Reset()
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			s.srv.Handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.logged, strings.Contains(out.String(), "Request handling took"))
+		})
+	}
+}
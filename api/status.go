@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"packs-api/internal/store"
+)
+
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+// statusResponse is the body of GET /status and GET /status/live.
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+// dependencyStatus is the outcome of a readiness check of one dependency.
+type dependencyStatus struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+// readinessResponse is the body of GET /status/ready.
+type readinessResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]dependencyStatus `json:"dependencies"`
+}
+
+// healthCheck reports whether a dependency can serve requests.
+type healthCheck func(ctx context.Context) bool
+
+func (s *Server) HandleStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.logger(r.Context()).Info("Status API called")
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status": "ok"}`))
+	}
+}
+
+// HandleLive reports that the process is up and serving requests, without
+// checking its dependencies.
+func (s *Server) HandleLive() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, statusResponse{Status: statusOK})
+	}
+}
+
+// HandleReady checks each dependency, giving each HealthCheckTimeout to
+// answer, and responds with 503 unless all of them are up.
+func (s *Server) HandleReady(mongoDB store.NoSQLStore) http.HandlerFunc {
+	checks := map[string]healthCheck{
+		"mongodb": func(ctx context.Context) bool { return mongoDB.CheckHealth(ctx) },
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := readinessResponse{Status: statusOK, Dependencies: make(map[string]dependencyStatus, len(checks))}
+		for name, check := range checks {
+			dep := s.checkDependency(r.Context(), check)
+			if dep.Status != statusOK {
+				res.Status = statusUnavailable
+				s.logger(r.Context()).WithField("dependency", name).WithField("error", dep.Error).Warn("dependency is not ready")
+			}
+			res.Dependencies[name] = dep
+		}
+
+		code := http.StatusOK
+		if res.Status != statusOK {
+			code = http.StatusServiceUnavailable
+		}
+		s.writeJSON(w, code, res)
+	}
+}
+
+func (s *Server) checkDependency(ctx context.Context, check healthCheck) dependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, s.HealthCheckTimeout)
+	defer cancel()
+
+	start := s.Time.Now()
+	ok := check(ctx)
+	dep := dependencyStatus{Status: statusOK, Latency: s.Time.Now().Sub(start).String()}
+	if !ok {
+		dep.Status = statusUnavailable
+		dep.Error = "health check failed"
+		if ctx.Err() == context.DeadlineExceeded {
+			dep.Error = "health check timed out after " + s.HealthCheckTimeout.String()
+		}
+	}
+
+	return dep
+}
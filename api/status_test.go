@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"packs-api/internal/utils"
+	"packs-api/mocks"
+)
+
+func TestServer_HandleLive(t *testing.T) {
+	s := new(Server)
+	s.Log = utils.NewLogger("test", "packs-api")
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/status/live", nil)
+	rr := httptest.NewRecorder()
+	s.HandleLive()(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status": "ok"}`, rr.Body.String())
+}
+
+func TestServer_HandleReady(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	now := time.Date(2023, 11, 04, 20, 34, 58, 651387237, time.UTC)
+	freezedTime := mocks.NewMockTime(ctrl)
+	// Each check takes 3ms
+	freezedTime.EXPECT().Now().DoAndReturn(func() time.Time {
+		now = now.Add(3 * time.Millisecond)
+		return now
+	}).AnyTimes()
+
+	tests := []struct {
+		name     string
+		health   func(ctx context.Context) bool
+		status   int
+		expected map[string]interface{}
+	}{
+		{
+			"ready", func(ctx context.Context) bool { return true }, 200,
+			map[string]interface{}{
+				"status":       "ok",
+				"dependencies": map[string]interface{}{"mongodb": map[string]interface{}{"status": "ok", "latency": "3ms"}},
+			},
+		},
+		{
+			"store down", func(ctx context.Context) bool { return false }, 503,
+			map[string]interface{}{
+				"status": "unavailable",
+				"dependencies": map[string]interface{}{
+					"mongodb": map[string]interface{}{"status": "unavailable", "latency": "3ms", "error": "health check failed"},
+				},
+			},
+		},
+		{
+			"store too slow", func(ctx context.Context) bool { <-ctx.Done(); return false }, 503,
+			map[string]interface{}{
+				"status": "unavailable",
+				"dependencies": map[string]interface{}{
+					"mongodb": map[string]interface{}{"status": "unavailable", "latency": "3ms", "error": "health check timed out after 10ms"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mongoDB := mocks.NewMockNoSQLStore(ctrl)
+			mongoDB.EXPECT().CheckHealth(gomock.Any()).DoAndReturn(tt.health).Times(1)
+
+			s := new(Server)
+			s.Log = utils.NewLogger("test", "packs-api")
+			s.Time = freezedTime
+			s.HealthCheckTimeout = 10 * time.Millisecond
+
+			req, _ := http.NewRequest(http.MethodGet, "/api/status/ready", nil)
+			rr := httptest.NewRecorder()
+			s.HandleReady(mongoDB)(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			var res map[string]interface{}
+			assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &res))
+			assert.Equal(t, tt.expected, res)
+		})
+	}
+}
@@ -2,31 +2,57 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
+
+	"github.com/sirupsen/logrus"
 
 	"packs-api/api"
 	"packs-api/internal/config"
 	"packs-api/internal/utils"
 )
 
-const addr = ":8001"
-
 func main() {
-	cfg, err := config.NewConfig(addr)
+	printConfig := flag.Bool("print-config", false, "print the settings, with secrets redacted, and exit")
+	settings, err := config.Load(flag.CommandLine, os.Args[1:], os.LookupEnv)
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%s", err)
+	}
+
+	if *printConfig {
+		if err := settings.Print(os.Stdout); err != nil {
+			log.Fatalln("could not print config, ", err)
+		}
+		return
+	}
+
+	cfg, err := config.NewConfig(settings)
 	if err != nil {
 		log.Fatalln("could not setup config, ", err)
 	}
 
-	logger := utils.NewLogger("dev", "packs-api")
+	logger := utils.NewLogger(settings.Log.Environment, "packs-api")
+	// The level was checked by config.Load
+	level, _ := logrus.ParseLevel(settings.Log.Level)
+	logger.Logger.SetLevel(level)
 	s := api.NewServer(cfg, logger)
 
+	// The readiness probe fails until MongoDB is reachable, so the indexes
+	// are created in the background rather than holding up the start
+	ctxIndexes, cancelIndexes := context.WithCancel(context.Background())
+	defer cancelIndexes()
+	go cfg.RetryEnsureIndexes(ctxIndexes, s.Log)
+
 	go func() {
-		err = s.ListenAndServe()
+		if settings.TLS.CertFile != "" {
+			err = s.ListenAndServeTLS(settings.TLS.CertFile, settings.TLS.KeyFile)
+		} else {
+			err = s.ListenAndServe()
+		}
 		if err != nil {
 			fmt.Println(err)
 		}
@@ -40,12 +66,16 @@ func main() {
 	s.Log.Info("shutting down gracefully...")
 
 	// Context for server shutdown
-	ctxShutDown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctxShutDown, cancel := context.WithTimeout(context.Background(), settings.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := s.Shutdown(ctxShutDown); err != nil {
 		s.Log.Fatalf("server forced to shutdown: %v", err)
 	}
 
+	if err := cfg.Tracer.Shutdown(ctxShutDown); err != nil {
+		s.Log.WithField("error", err.Error()).Error("failed to export the remaining spans")
+	}
+
 	s.Log.Info("server exiting...")
 }
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func writeJWKS(t *testing.T, keys ...jwk) string {
+	t.Helper()
+	b, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadJWKSVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := writeJWKS(t,
+		jwk{Kty: "RSA", Kid: "rsa", N: encodeBigInt(rsaKey.N), E: encodeBigInt(big.NewInt(int64(rsaKey.E)))},
+		jwk{Kty: "EC", Kid: "ec", Crv: "P-256", X: encodeBigInt(ecKey.X), Y: encodeBigInt(ecKey.Y)},
+	)
+	v, err := LoadJWKSVerifier(path, "", "")
+	if err != nil {
+		t.Fatalf("LoadJWKSVerifier() error = %v", err)
+	}
+
+	claims := &Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "ann", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"rsa", sign(jwt.SigningMethodRS256, "rsa", rsaKey), false},
+		{"ec", sign(jwt.SigningMethodES256, "ec", ecKey), false},
+		{"unknown kid", sign(jwt.SigningMethodRS256, "other", rsaKey), true},
+		{"missing kid with several keys", sign(jwt.SigningMethodRS256, "", rsaKey), true},
+		{"kid of another key", sign(jwt.SigningMethodRS256, "ec", rsaKey), true},
+		{"hmac", sign(jwt.SigningMethodHS256, "rsa", []byte("secret")), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(tt.token)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() error = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestLoadJWKSVerifier_SingleKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeJWKS(t, jwk{Kty: "RSA", N: encodeBigInt(rsaKey.N), E: encodeBigInt(big.NewInt(int64(rsaKey.E)))})
+
+	v, err := LoadJWKSVerifier(path, "", "")
+	if err != nil {
+		t.Fatalf("LoadJWKSVerifier() error = %v", err)
+	}
+
+	claims := &Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "ann", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(token); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestLoadJWKSVerifier_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []jwk
+	}{
+		{"no keys", nil},
+		{"unknown type", []jwk{{Kty: "oct"}}},
+		{"unknown curve", []jwk{{Kty: "EC", Crv: "P-224", X: "AQ", Y: "AQ"}}},
+		{"point off curve", []jwk{{Kty: "EC", Crv: "P-256", X: "AQ", Y: "AQ"}}},
+		{"missing modulus", []jwk{{Kty: "RSA", E: "AQAB"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadJWKSVerifier(writeJWKS(t, tt.keys...), "", ""); err == nil {
+				t.Error("LoadJWKSVerifier() error = nil, want an error")
+			}
+		})
+	}
+}
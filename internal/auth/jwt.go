@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned for bearer tokens that fail verification.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are the JWT claims read by the API. Roles holds role names, the
+// highest of which applies.
+type Claims struct {
+	Name  string   `json:"name,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Verifier checks the signature and the registered claims of bearer tokens.
+type Verifier struct {
+	parser  *jwt.Parser
+	keyFunc jwt.Keyfunc
+}
+
+// NewHMACVerifier returns a Verifier for tokens signed with the shared secret.
+// An empty issuer or audience is not checked.
+func NewHMACVerifier(secret []byte, issuer, audience string) *Verifier {
+	return newVerifier([]string{"HS256", "HS384", "HS512"}, issuer, audience, func(*jwt.Token) (interface{}, error) {
+		return secret, nil
+	})
+}
+
+func newVerifier(methods []string, issuer, audience string, keyFunc jwt.Keyfunc) *Verifier {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+
+	return &Verifier{parser: jwt.NewParser(opts...), keyFunc: keyFunc}
+}
+
+// Verify parses the token and returns its claims. Tokens without a subject
+// are rejected, since the subject identifies the caller.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	claims := new(Claims)
+	_, err := v.parser.ParseWithClaims(token, claims, v.keyFunc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
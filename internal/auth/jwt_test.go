@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	return token
+}
+
+func TestVerifier_HMAC(t *testing.T) {
+	secret := []byte("secret")
+	exp := jwt.NewNumericDate(time.Now().Add(time.Hour))
+	valid := &Claims{
+		Name:             "Ann",
+		Roles:            []string{"viewer", "operator"},
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "ann", Issuer: "idp", Audience: jwt.ClaimStrings{"packs-api"}, ExpiresAt: exp},
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"valid", signToken(t, jwt.SigningMethodHS256, secret, valid), false},
+		{"wrong secret", signToken(t, jwt.SigningMethodHS256, []byte("other"), valid), true},
+		{"expired", signToken(t, jwt.SigningMethodHS256, secret, &Claims{RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "ann", Issuer: "idp", Audience: jwt.ClaimStrings{"packs-api"}, ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		}}), true},
+		{"no expiry", signToken(t, jwt.SigningMethodHS256, secret, &Claims{RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "ann", Issuer: "idp", Audience: jwt.ClaimStrings{"packs-api"},
+		}}), true},
+		{"no subject", signToken(t, jwt.SigningMethodHS256, secret, &Claims{RegisteredClaims: jwt.RegisteredClaims{
+			Issuer: "idp", Audience: jwt.ClaimStrings{"packs-api"}, ExpiresAt: exp,
+		}}), true},
+		{"wrong issuer", signToken(t, jwt.SigningMethodHS256, secret, &Claims{RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "ann", Issuer: "other", Audience: jwt.ClaimStrings{"packs-api"}, ExpiresAt: exp,
+		}}), true},
+		{"wrong audience", signToken(t, jwt.SigningMethodHS256, secret, &Claims{RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "ann", Issuer: "idp", Audience: jwt.ClaimStrings{"other"}, ExpiresAt: exp,
+		}}), true},
+		{"unsigned", signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid), true},
+		{"malformed", "not.a.token", true},
+	}
+
+	v := NewHMACVerifier(secret, "idp", "packs-api")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Verify() error = %v, want %v", err, ErrInvalidToken)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if claims.Subject != "ann" || claims.Name != "Ann" || HighestRole(claims.Roles) != RoleOperator {
+				t.Errorf("Verify() claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestHighestRole(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  Role
+	}{
+		{nil, RoleNone},
+		{[]string{"guest"}, RoleNone},
+		{[]string{"viewer"}, RoleViewer},
+		{[]string{"admin", "viewer"}, RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		if got := HighestRole(tt.names); got != tt.want {
+			t.Errorf("HighestRole(%v) = %v, want %v", tt.names, got, tt.want)
+		}
+	}
+}
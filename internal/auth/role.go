@@ -0,0 +1,41 @@
+package auth
+
+// Role grants access to a set of routes. Each role includes the ones below it.
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleViewer
+	RoleOperator
+	RoleAdmin
+)
+
+var roleNames = map[string]Role{
+	"viewer":   RoleViewer,
+	"operator": RoleOperator,
+	"admin":    RoleAdmin,
+}
+
+// ParseRole returns the role with the given name, or RoleNone for an unknown
+// name.
+func ParseRole(name string) Role {
+	return roleNames[name]
+}
+
+// HighestRole returns the highest known role among names.
+func HighestRole(names []string) Role {
+	role := RoleNone
+	for _, name := range names {
+		role = max(role, ParseRole(name))
+	}
+	return role
+}
+
+func (r Role) String() string {
+	for name, role := range roleNames {
+		if role == r {
+			return name
+		}
+	}
+	return "none"
+}
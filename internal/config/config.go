@@ -1,52 +1,258 @@
 package config
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"strings"
+	"time"
 
+	"github.com/sirupsen/logrus"
+
+	"packs-api/internal/auth"
 	"packs-api/internal/store"
+	"packs-api/internal/tracing"
 )
 
 type Config struct {
-	Addr                   string
-	PathPrefix             string
-	MongoDB                store.NoSQLStore
-	AllowedOrigins         []string
+	Addr       string
+	PathPrefix string
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout are
+	// those of the http.Server, zero for none.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MongoDB           store.NoSQLStore
+	AllowedOrigins    []string
+	// CORSMaxAge is how long browsers may cache preflight responses, zero
+	// to not say.
+	CORSMaxAge             time.Duration
 	SkipHealthCheckLogging bool
+	MaxPackSize            int
+	// MaxItems is the largest order that is packed, zero for no limit.
+	MaxItems int
+	// APIKeyAuth requires every request outside /status to carry a known
+	// X-API-KEY header.
+	APIKeyAuth bool
+	// JWTVerifier checks Authorization bearer tokens, nil when bearer tokens
+	// are not accepted.
+	JWTVerifier *auth.Verifier
+	// ReadRateLimit applies to GET, HEAD and OPTIONS requests and
+	// WriteRateLimit to all others, per API key or client IP.
+	ReadRateLimit  RateLimit
+	WriteRateLimit RateLimit
+	// IdempotencyTTL is how long the response to a request with an
+	// Idempotency-Key header is kept for replays.
+	IdempotencyTTL time.Duration
+	// MaxBodyBytes is the largest request body that is read, zero for no
+	// limit.
+	MaxBodyBytes int64
+	// APIVersions are served below PathPrefix/<name>, oldest first. The
+	// first is also served below PathPrefix itself.
+	APIVersions []APIVersion
+	// HealthCheckTimeout bounds each dependency check of the readiness
+	// probe.
+	HealthCheckTimeout time.Duration
+	// MetricsPath is where the Prometheus metrics are served, empty to not
+	// serve them.
+	MetricsPath string
+	// Tracer records the spans of requests, the solver and MongoDB.
+	Tracer tracing.Tracer
+	// AccessLog configures the line logged for every request.
+	AccessLog AccessLog
+	// EnsureIndexes creates the MongoDB indexes, see RetryEnsureIndexes.
+	EnsureIndexes func(context.Context) error
+}
+
+// AccessLog configures the access log. Bodies are only logged when they are
+// JSON and fit within MaxBodyBytes, since redaction needs the whole
+// document.
+type AccessLog struct {
+	// Level is the level of the access log lines.
+	Level logrus.Level
+	// BodySampleRate is the fraction of requests, from 0 to 1, whose
+	// request and response bodies are logged.
+	BodySampleRate float64
+	// MaxBodyBytes is the largest body that is logged.
+	MaxBodyBytes int
+	// RedactFields are the names of JSON fields, at any depth, whose values
+	// are replaced in logged bodies. Names match regardless of case.
+	RedactFields []string
+	// SkipRoutes are the route templates, e.g. /api/orders/{id}, whose
+	// requests are not logged.
+	SkipRoutes []string
+}
+
+// APIVersion is a version of the API routes. A zero Deprecation or Sunset
+// leaves out the header of the same name.
+type APIVersion struct {
+	Name string
+	// Deprecation is when the version was or will be deprecated.
+	Deprecation time.Time
+	// Sunset is when the version stops being served.
+	Sunset time.Time
+}
+
+// RateLimit allows Burst requests at once and Rate requests per second on
+// average. A zero Rate disables the limit.
+type RateLimit struct {
+	Rate  float64 `yaml:"rps" toml:"rps" env:"RPS"`
+	Burst int     `yaml:"burst" toml:"burst" env:"BURST"`
+}
+
+// defaultMaxPackSize bounds the memory used by the packing solver, which grows
+// with the largest pack size.
+const defaultMaxPackSize = 10000
+
+// defaultMaxItems keeps orders far from where the solver's amounts overflow.
+const defaultMaxItems = 1_000_000_000
+
+const defaultIdempotencyTTL = 24 * time.Hour
+
+const defaultMaxBodyBytes = 1 << 20
+
+const defaultHealthCheckTimeout = 2 * time.Second
+
+const defaultMetricsPath = "/metrics"
+
+// indexTimeout bounds each attempt at creating the MongoDB indexes, which
+// waits for the server to be reachable.
+const indexTimeout = 30 * time.Second
+
+// Failed attempts at creating the MongoDB indexes are retried after
+// indexRetryDelay, doubling up to maxIndexRetryDelay.
+const (
+	indexRetryDelay    = time.Second
+	maxIndexRetryDelay = time.Minute
+)
+
+var defaultAccessLog = AccessLog{
+	Level:        logrus.InfoLevel,
+	MaxBodyBytes: 4096,
+	RedactFields: []string{"password", "secret", "token", "apiKey", "authorization"},
 }
 
-func NewConfig(addr string) (*Config, error) {
+const defaultTracingExporter = tracing.ExporterElastic
+
+var (
+	defaultReadRateLimit  = RateLimit{Rate: 20, Burst: 40}
+	defaultWriteRateLimit = RateLimit{Rate: 5, Burst: 10}
+)
+
+// NewConfig makes the connections and clients configured by s, which must
+// have passed Validate.
+func NewConfig(s *Settings) (*Config, error) {
 	cfg := new(Config)
-	cfg.Addr = addr
+	cfg.Addr = s.Server.Addr
 	cfg.PathPrefix = "/api"
+	cfg.ReadHeaderTimeout = s.Server.ReadHeaderTimeout
+	cfg.ReadTimeout = s.Server.ReadTimeout
+	cfg.WriteTimeout = s.Server.WriteTimeout
+	cfg.IdleTimeout = s.Server.IdleTimeout
+	cfg.AllowedOrigins = s.CORS.AllowedOrigins
+	cfg.CORSMaxAge = s.CORS.MaxAge
+	cfg.SkipHealthCheckLogging = s.Log.SkipHealthCheckLogging
+	cfg.MaxPackSize = s.Solver.MaxPackSize
+	cfg.MaxItems = s.Solver.MaxItems
+	cfg.APIKeyAuth = s.Auth.APIKeyAuth
+	cfg.ReadRateLimit = s.RateLimit.Read
+	cfg.WriteRateLimit = s.RateLimit.Write
+	cfg.IdempotencyTTL = s.Server.IdempotencyTTL
+	cfg.MaxBodyBytes = s.Server.MaxBodyBytes
+	cfg.HealthCheckTimeout = s.Server.HealthCheckTimeout
+	cfg.MetricsPath = s.Server.MetricsPath
+	cfg.APIVersions = []APIVersion{
+		{Name: "v1", Deprecation: s.APIVersions.V1.Deprecation, Sunset: s.APIVersions.V1.Sunset},
+		{Name: "v2", Deprecation: s.APIVersions.V2.Deprecation, Sunset: s.APIVersions.V2.Sunset},
+	}
 
-	ao, ok := os.LookupEnv("ALLOWED_ORIGINS")
-	if !ok {
-		cfg.AllowedOrigins = []string{"*"}
-	} else {
-		cfg.AllowedOrigins = strings.Split(ao, ",")
+	accessLogLevel, err := logrus.ParseLevel(s.AccessLog.Level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid accessLog.level: %s", err)
+	}
+	cfg.AccessLog = AccessLog{
+		Level:          accessLogLevel,
+		BodySampleRate: s.AccessLog.BodySampleRate,
+		MaxBodyBytes:   s.AccessLog.MaxBodyBytes,
+		RedactFields:   s.AccessLog.RedactFields,
+		SkipRoutes:     s.AccessLog.SkipRoutes,
 	}
 
-	m, err := getMongoDB()
+	v, err := getJWTVerifier(s.Auth)
 	if err != nil {
 		return nil, err
 	}
+	cfg.JWTVerifier = v
+
+	t, err := tracing.New(s.Tracing.Exporter, s.Tracing.File, "packs-api")
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracing.exporter: %s", err)
+	}
+	cfg.Tracer = t
 
-	cfg.MongoDB = m
+	m, err := getMongoDB(s.MongoDB)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.MongoDB = store.NewTracedStore(store.NewInstrumentedStore(m), t)
+	cfg.EnsureIndexes = m.EnsureIndexes
 
 	return cfg, nil
 }
 
-func getMongoDB() (store.NoSQLStore, error) {
-	mongoURI := os.Getenv("MONGODB_URI")
-	mongoDBName := os.Getenv("MONGODB_DATABASE_NAME")
-	mongoCertPath := os.Getenv("MONGODB_CERT_PATH")
+// getJWTVerifier checks tokens against either the HMAC secret or the keys in
+// the JWKS file. The issuer and audience are checked when set.
+func getJWTVerifier(s AuthSettings) (*auth.Verifier, error) {
+	switch {
+	case s.JWTHMACSecret != "":
+		return auth.NewHMACVerifier([]byte(s.JWTHMACSecret), s.JWTIssuer, s.JWTAudience), nil
+	case s.JWTJWKSFile != "":
+		v, err := auth.LoadJWKSVerifier(s.JWTJWKSFile, s.JWTIssuer, s.JWTAudience)
+		if err != nil {
+			return nil, fmt.Errorf("error loading auth.jwtJWKSFile: %s", err)
+		}
+		return v, nil
+	default:
+		return nil, nil
+	}
+}
 
-	mongoDB, err := store.NewMongoDB(mongoURI, mongoDBName, mongoCertPath)
+func getMongoDB(s MongoDBSettings) (*store.MongoDB, error) {
+	mongoDB, err := store.NewMongoDB(s.URI, s.DatabaseName, s.CertPath, store.PoolOptions{
+		MaxPoolSize:     s.MaxPoolSize,
+		MinPoolSize:     s.MinPoolSize,
+		MaxConnIdleTime: s.MaxConnIdleTime,
+		ConnectTimeout:  s.ConnectTimeout,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to MongoDB: %s", err)
 	}
 
 	return mongoDB, nil
 }
+
+// RetryEnsureIndexes calls c.EnsureIndexes until it succeeds or ctx is
+// done, logging every failure. It is meant to run in the background, so that
+// the service starts, and reports not ready, while MongoDB is unreachable.
+func (c *Config) RetryEnsureIndexes(ctx context.Context, log *logrus.Entry) {
+	retryEnsureIndexes(ctx, c.EnsureIndexes, log, indexRetryDelay, maxIndexRetryDelay)
+}
+
+func retryEnsureIndexes(ctx context.Context, ensure func(context.Context) error, log *logrus.Entry, delay, maxDelay time.Duration) {
+	for {
+		attemptCtx, cancel := context.WithTimeout(ctx, indexTimeout)
+		err := ensure(attemptCtx)
+		cancel()
+		if err == nil {
+			return
+		}
+		log.WithFields(logrus.Fields{"error": err.Error(), "retryIn": delay.String()}).Error("failed to create the MongoDB indexes")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxDelay)
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryEnsureIndexes(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+
+	calls := 0
+	ensure := func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("server selection timeout")
+		}
+		return nil
+	}
+
+	retryEnsureIndexes(context.Background(), ensure, logrus.NewEntry(logger), time.Millisecond, 2*time.Millisecond)
+
+	assert.Equal(t, 3, calls)
+	assert.Len(t, hook.AllEntries(), 2)
+	assert.Equal(t, "failed to create the MongoDB indexes", hook.LastEntry().Message)
+	assert.Equal(t, "2ms", hook.LastEntry().Data["retryIn"])
+}
+
+func TestRetryEnsureIndexes_Canceled(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	ensure := func(ctx context.Context) error {
+		calls++
+		cancel()
+		return ctx.Err()
+	}
+
+	retryEnsureIndexes(ctx, ensure, logrus.NewEntry(logger), time.Hour, time.Hour)
+
+	assert.Equal(t, 1, calls)
+}
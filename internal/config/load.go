@@ -0,0 +1,269 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileEnv names the variable holding the config file, when the
+// -config flag is not given.
+const configFileEnv = "CONFIG_FILE"
+
+// Load returns the settings layered from DefaultSettings, the YAML or TOML
+// file in -config or CONFIG_FILE, the variables found by lookupEnv and the
+// flags in args, each overriding the ones before. It registers its flags on
+// fs, which may hold others, and parses args with it.
+//
+// Every invalid value, in any layer, is reported in the returned error, as
+// well as every setting that fails Validate.
+func Load(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (*Settings, error) {
+	s := DefaultSettings()
+	fields := settingFields(reflect.ValueOf(s).Elem(), "", "")
+
+	configFile := fs.String("config", "", "YAML or TOML `file` to read the settings from, also "+configFileEnv)
+	flags := make(map[string]*settingFlag, len(fields))
+	for _, f := range fields {
+		sf := &settingFlag{isBool: f.value.Kind() == reflect.Bool}
+		fs.Var(sf, f.flag, "overrides "+f.env)
+		fs.Lookup(f.flag).DefValue = formatValue(f.value)
+		flags[f.flag] = sf
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+
+	file := *configFile
+	if file == "" {
+		file, _ = lookupEnv(configFileEnv)
+	}
+	if file != "" {
+		errs = append(errs, loadFile(s, file)...)
+	}
+
+	for _, f := range fields {
+		if v, ok := lookupEnv(f.env); ok {
+			if err := setValue(f.value, v); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %q", f.env, v))
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if v := flags[f.flag].value; v != nil {
+			if err := setValue(f.value, *v); err != nil {
+				errs = append(errs, fmt.Errorf("invalid -%s: %q", f.flag, *v))
+			}
+		}
+	}
+
+	if err := s.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadFile decodes the file at path over s, picking the format from its
+// extension. Keys that are not settings are errors, so typos are not
+// silently ignored.
+func loadFile(s *Settings, path string) []error {
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		f, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			return []error{fmt.Errorf("error reading config file: %s", err)}
+		}
+		defer f.Close()
+
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		err = dec.Decode(s)
+		var typeErr *yaml.TypeError
+		switch {
+		case err == nil, errors.Is(err, io.EOF):
+			return nil
+		case errors.As(err, &typeErr):
+			errs := make([]error, 0, len(typeErr.Errors))
+			for _, e := range typeErr.Errors {
+				errs = append(errs, fmt.Errorf("invalid config file %s: %s", path, e))
+			}
+			return errs
+		default:
+			return []error{fmt.Errorf("invalid config file %s: %s", path, err)}
+		}
+	case ".toml":
+		md, err := toml.DecodeFile(path, s)
+		if err != nil {
+			return []error{fmt.Errorf("invalid config file %s: %s", path, err)}
+		}
+		var errs []error
+		for _, key := range md.Undecoded() {
+			errs = append(errs, fmt.Errorf("invalid config file %s: unknown setting %s", path, key))
+		}
+		return errs
+	default:
+		return []error{fmt.Errorf("invalid config file %s: the extension must be .yaml, .yml or .toml", path)}
+	}
+}
+
+// settingField is a leaf of Settings, with the names of its variable and
+// flag.
+type settingField struct {
+	value reflect.Value
+	env   string
+	flag  string
+}
+
+// settingFields returns the leaves of the struct v. envPrefix and
+// flagPrefix are those of v itself, empty for Settings.
+func settingFields(v reflect.Value, envPrefix, flagPrefix string) []settingField {
+	var fields []settingField
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		key, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		env := sf.Tag.Get("env")
+		if envPrefix != "" && env != "" {
+			env = envPrefix + "_" + env
+		}
+		if flagPrefix != "" {
+			key = flagPrefix + "." + key
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+			fields = append(fields, settingFields(fv, env, key)...)
+			continue
+		}
+		fields = append(fields, settingField{value: fv, env: env, flag: key})
+	}
+	return fields
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// setValue parses s into v. Durations are in the time.ParseDuration format,
+// times in RFC 3339 and lists are comma separated.
+func setValue(v reflect.Value, s string) error {
+	switch v.Type() {
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		v.Set(reflect.ValueOf(splitList(s)))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// formatValue is the inverse of setValue.
+func formatValue(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Type() == timeType:
+		if t := v.Interface().(time.Time); !t.IsZero() {
+			return t.Format(time.RFC3339)
+		}
+		return ""
+	case v.Kind() == reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// splitList splits a comma separated list, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// settingFlag keeps the value given to a setting's flag, which is applied
+// after the variables.
+type settingFlag struct {
+	// value is nil when the flag is not given.
+	value  *string
+	isBool bool
+}
+
+func (f *settingFlag) String() string {
+	if f == nil || f.value == nil {
+		return ""
+	}
+	return *f.value
+}
+
+func (f *settingFlag) Set(v string) error {
+	f.value = &v
+	return nil
+}
+
+// IsBoolFlag lets boolean settings be given as -flag rather than -flag=true.
+func (f *settingFlag) IsBoolFlag() bool {
+	return f.isBool
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings are the plain values the service is configured with, before any
+// connection is made. They are layered by Load: the defaults, then a YAML or
+// TOML file, then environment variables, then command line flags.
+//
+// Each leaf field is read from the variable in its env tag, prefixed by the
+// env tags of the structs it is in, and from the flag named after its path
+// of yaml keys, e.g. -server.readTimeout.
+type Settings struct {
+	Server      ServerSettings      `yaml:"server" toml:"server"`
+	TLS         TLSSettings         `yaml:"tls" toml:"tls"`
+	MongoDB     MongoDBSettings     `yaml:"mongodb" toml:"mongodb"`
+	CORS        CORSSettings        `yaml:"cors" toml:"cors"`
+	Log         LogSettings         `yaml:"log" toml:"log"`
+	AccessLog   AccessLogSettings   `yaml:"accessLog" toml:"accessLog"`
+	RateLimit   RateLimitSettings   `yaml:"rateLimit" toml:"rateLimit"`
+	Solver      SolverSettings      `yaml:"solver" toml:"solver"`
+	Auth        AuthSettings        `yaml:"auth" toml:"auth"`
+	Tracing     TracingSettings     `yaml:"tracing" toml:"tracing"`
+	APIVersions APIVersionsSettings `yaml:"apiVersions" toml:"apiVersions"`
+}
+
+// ServerSettings configure the HTTP server. A zero timeout means no timeout.
+type ServerSettings struct {
+	Addr              string        `yaml:"addr" toml:"addr" env:"LISTEN_ADDR"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout" toml:"readHeaderTimeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	ReadTimeout       time.Duration `yaml:"readTimeout" toml:"readTimeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout" toml:"writeTimeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idleTimeout" toml:"idleTimeout" env:"SERVER_IDLE_TIMEOUT"`
+	// ShutdownTimeout bounds the graceful shutdown.
+	ShutdownTimeout    time.Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	MaxBodyBytes       int64         `yaml:"maxBodyBytes" toml:"maxBodyBytes" env:"MAX_BODY_BYTES"`
+	IdempotencyTTL     time.Duration `yaml:"idempotencyTTL" toml:"idempotencyTTL" env:"IDEMPOTENCY_TTL"`
+	HealthCheckTimeout time.Duration `yaml:"healthCheckTimeout" toml:"healthCheckTimeout" env:"HEALTH_CHECK_TIMEOUT"`
+	MetricsPath        string        `yaml:"metricsPath" toml:"metricsPath" env:"METRICS_PATH"`
+}
+
+// TLSSettings make the server serve HTTPS when both files are set.
+type TLSSettings struct {
+	CertFile string `yaml:"certFile" toml:"certFile" env:"TLS_CERT_FILE"`
+	KeyFile  string `yaml:"keyFile" toml:"keyFile" env:"TLS_KEY_FILE"`
+}
+
+// MongoDBSettings configure the connection to MongoDB and its pool. Zero
+// pool settings keep those of the URI, or the driver defaults.
+type MongoDBSettings struct {
+	URI             string        `yaml:"uri" toml:"uri" env:"MONGODB_URI"`
+	DatabaseName    string        `yaml:"databaseName" toml:"databaseName" env:"MONGODB_DATABASE_NAME"`
+	CertPath        string        `yaml:"certPath" toml:"certPath" env:"MONGODB_CERT_PATH"`
+	MaxPoolSize     uint64        `yaml:"maxPoolSize" toml:"maxPoolSize" env:"MONGODB_MAX_POOL_SIZE"`
+	MinPoolSize     uint64        `yaml:"minPoolSize" toml:"minPoolSize" env:"MONGODB_MIN_POOL_SIZE"`
+	MaxConnIdleTime time.Duration `yaml:"maxConnIdleTime" toml:"maxConnIdleTime" env:"MONGODB_MAX_CONN_IDLE_TIME"`
+	ConnectTimeout  time.Duration `yaml:"connectTimeout" toml:"connectTimeout" env:"MONGODB_CONNECT_TIMEOUT"`
+}
+
+// CORSSettings configure the CORS headers. A zero MaxAge leaves out the
+// Access-Control-Max-Age header.
+type CORSSettings struct {
+	AllowedOrigins []string      `yaml:"allowedOrigins" toml:"allowedOrigins" env:"ALLOWED_ORIGINS"`
+	MaxAge         time.Duration `yaml:"maxAge" toml:"maxAge" env:"CORS_MAX_AGE"`
+}
+
+// LogSettings configure the service logger.
+type LogSettings struct {
+	Environment            string `yaml:"environment" toml:"environment" env:"LOG_ENVIRONMENT"`
+	Level                  string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	SkipHealthCheckLogging bool   `yaml:"skipHealthCheckLogging" toml:"skipHealthCheckLogging" env:"SKIP_HEALTH_CHECK_LOGGING"`
+}
+
+// AccessLogSettings are the settings of AccessLog.
+type AccessLogSettings struct {
+	Level          string   `yaml:"level" toml:"level" env:"ACCESS_LOG_LEVEL"`
+	BodySampleRate float64  `yaml:"bodySampleRate" toml:"bodySampleRate" env:"ACCESS_LOG_BODY_SAMPLE_RATE"`
+	MaxBodyBytes   int      `yaml:"maxBodyBytes" toml:"maxBodyBytes" env:"ACCESS_LOG_MAX_BODY_BYTES"`
+	RedactFields   []string `yaml:"redactFields" toml:"redactFields" env:"ACCESS_LOG_REDACT_FIELDS"`
+	SkipRoutes     []string `yaml:"skipRoutes" toml:"skipRoutes" env:"ACCESS_LOG_SKIP_ROUTES"`
+}
+
+// RateLimitSettings are the limits of read and write requests, see
+// Config.ReadRateLimit.
+type RateLimitSettings struct {
+	Read  RateLimit `yaml:"read" toml:"read" env:"RATE_LIMIT_READ"`
+	Write RateLimit `yaml:"write" toml:"write" env:"RATE_LIMIT_WRITE"`
+}
+
+// SolverSettings bound the work of the packing solver.
+type SolverSettings struct {
+	MaxPackSize int `yaml:"maxPackSize" toml:"maxPackSize" env:"MAX_PACK_SIZE"`
+	MaxItems    int `yaml:"maxItems" toml:"maxItems" env:"MAX_ITEMS"`
+}
+
+// AuthSettings configure how callers authenticate. At most one of
+// JWTHMACSecret and JWTJWKSFile can be set.
+type AuthSettings struct {
+	APIKeyAuth    bool   `yaml:"apiKeyAuth" toml:"apiKeyAuth" env:"API_KEY_AUTH"`
+	JWTHMACSecret string `yaml:"jwtHMACSecret" toml:"jwtHMACSecret" env:"JWT_HMAC_SECRET"`
+	JWTJWKSFile   string `yaml:"jwtJWKSFile" toml:"jwtJWKSFile" env:"JWT_JWKS_FILE"`
+	JWTIssuer     string `yaml:"jwtIssuer" toml:"jwtIssuer" env:"JWT_ISSUER"`
+	JWTAudience   string `yaml:"jwtAudience" toml:"jwtAudience" env:"JWT_AUDIENCE"`
+}
+
+// TracingSettings pick the tracing exporter. File is where the file
+// exporter writes.
+type TracingSettings struct {
+	Exporter string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	File     string `yaml:"file" toml:"file" env:"TRACING_FILE"`
+}
+
+// APIVersionsSettings are the lifecycle dates of each API version.
+type APIVersionsSettings struct {
+	V1 APIVersionSettings `yaml:"v1" toml:"v1" env:"API_V1"`
+	V2 APIVersionSettings `yaml:"v2" toml:"v2" env:"API_V2"`
+}
+
+// APIVersionSettings are the dates of an APIVersion. Zero dates are not
+// announced.
+type APIVersionSettings struct {
+	Deprecation time.Time `yaml:"deprecation,omitempty" toml:"deprecation" env:"DEPRECATION"`
+	Sunset      time.Time `yaml:"sunset,omitempty" toml:"sunset" env:"SUNSET"`
+}
+
+// DefaultSettings returns the settings used when nothing else is given.
+func DefaultSettings() *Settings {
+	return &Settings{
+		Server: ServerSettings{
+			Addr:               ":8001",
+			ReadHeaderTimeout:  10 * time.Second,
+			ShutdownTimeout:    5 * time.Second,
+			MaxBodyBytes:       defaultMaxBodyBytes,
+			IdempotencyTTL:     defaultIdempotencyTTL,
+			HealthCheckTimeout: defaultHealthCheckTimeout,
+			MetricsPath:        defaultMetricsPath,
+		},
+		CORS: CORSSettings{
+			AllowedOrigins: []string{"*"},
+		},
+		Log: LogSettings{
+			Environment: "dev",
+			Level:       "info",
+		},
+		AccessLog: AccessLogSettings{
+			Level:        defaultAccessLog.Level.String(),
+			MaxBodyBytes: defaultAccessLog.MaxBodyBytes,
+			RedactFields: append([]string(nil), defaultAccessLog.RedactFields...),
+		},
+		RateLimit: RateLimitSettings{
+			Read:  defaultReadRateLimit,
+			Write: defaultWriteRateLimit,
+		},
+		Solver: SolverSettings{
+			MaxPackSize: defaultMaxPackSize,
+			MaxItems:    defaultMaxItems,
+		},
+		Tracing: TracingSettings{
+			Exporter: defaultTracingExporter,
+		},
+	}
+}
+
+// Redacted returns a copy of s whose secrets are replaced, so it can be
+// printed or logged: the JWT secret, and the password in the MongoDB URI.
+func (s *Settings) Redacted() *Settings {
+	r := *s
+	if r.Auth.JWTHMACSecret != "" {
+		r.Auth.JWTHMACSecret = redactedValue
+	}
+	r.MongoDB.URI = redactURI(r.MongoDB.URI)
+	return &r
+}
+
+// Print writes the redacted settings to w as YAML, in the layout of a
+// config file.
+func (s *Settings) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(s.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// redactedValue replaces secrets in redacted settings.
+const redactedValue = "[REDACTED]"
+
+// redactURI replaces the password in uri. A URI that does not parse is
+// replaced whole, as it may still hold one.
+func redactURI(uri string) string {
+	if uri == "" {
+		return uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return redactedValue
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "REDACTED")
+	}
+	return u.String()
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"packs-api/internal/tracing"
+)
+
+// maxCORSMaxAge is the longest preflight cache the CORS handler sends.
+const maxCORSMaxAge = 10 * time.Minute
+
+// Validate checks every setting and returns an error listing all of those
+// that are invalid, or nil.
+func (s *Settings) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("invalid %s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if s.Server.Addr == "" {
+		invalid("server.addr", "must be set")
+	}
+	for _, t := range []struct {
+		key      string
+		d        time.Duration
+		positive bool
+	}{
+		{"server.readHeaderTimeout", s.Server.ReadHeaderTimeout, false},
+		{"server.readTimeout", s.Server.ReadTimeout, false},
+		{"server.writeTimeout", s.Server.WriteTimeout, false},
+		{"server.idleTimeout", s.Server.IdleTimeout, false},
+		{"server.shutdownTimeout", s.Server.ShutdownTimeout, true},
+		{"server.idempotencyTTL", s.Server.IdempotencyTTL, true},
+		{"server.healthCheckTimeout", s.Server.HealthCheckTimeout, true},
+		{"mongodb.maxConnIdleTime", s.MongoDB.MaxConnIdleTime, false},
+		{"mongodb.connectTimeout", s.MongoDB.ConnectTimeout, false},
+	} {
+		switch {
+		case t.positive && t.d <= 0:
+			invalid(t.key, "must be positive, got %s", t.d)
+		case t.d < 0:
+			invalid(t.key, "must not be negative, got %s", t.d)
+		}
+	}
+	if s.Server.MaxBodyBytes < 0 {
+		invalid("server.maxBodyBytes", "must not be negative, got %d", s.Server.MaxBodyBytes)
+	}
+	if s.Server.MetricsPath != "" && !strings.HasPrefix(s.Server.MetricsPath, "/") {
+		invalid("server.metricsPath", "must start with /, got %q", s.Server.MetricsPath)
+	}
+
+	if (s.TLS.CertFile == "") != (s.TLS.KeyFile == "") {
+		invalid("tls", "certFile and keyFile must be set together")
+	}
+
+	if s.MongoDB.URI == "" {
+		invalid("mongodb.uri", "must be set")
+	}
+	if s.MongoDB.DatabaseName == "" {
+		invalid("mongodb.databaseName", "must be set")
+	}
+	if s.MongoDB.MaxPoolSize > 0 && s.MongoDB.MinPoolSize > s.MongoDB.MaxPoolSize {
+		invalid("mongodb.minPoolSize", "must not be above maxPoolSize %d, got %d", s.MongoDB.MaxPoolSize, s.MongoDB.MinPoolSize)
+	}
+
+	if s.CORS.MaxAge < 0 || s.CORS.MaxAge > maxCORSMaxAge {
+		invalid("cors.maxAge", "must be between 0s and %s, got %s", maxCORSMaxAge, s.CORS.MaxAge)
+	}
+
+	if s.Log.Environment == "" {
+		invalid("log.environment", "must be set")
+	}
+	if _, err := logrus.ParseLevel(s.Log.Level); err != nil {
+		invalid("log.level", "%q is not a level", s.Log.Level)
+	}
+
+	// Panic and fatal would stop the server after logging
+	if lvl, err := logrus.ParseLevel(s.AccessLog.Level); err != nil || lvl <= logrus.FatalLevel {
+		invalid("accessLog.level", "%q is not a level below fatal", s.AccessLog.Level)
+	}
+	if s.AccessLog.BodySampleRate < 0 || s.AccessLog.BodySampleRate > 1 {
+		invalid("accessLog.bodySampleRate", "must be between 0 and 1, got %v", s.AccessLog.BodySampleRate)
+	}
+	if s.AccessLog.MaxBodyBytes < 0 {
+		invalid("accessLog.maxBodyBytes", "must not be negative, got %d", s.AccessLog.MaxBodyBytes)
+	}
+
+	for _, l := range []struct {
+		key   string
+		limit RateLimit
+	}{
+		{"rateLimit.read", s.RateLimit.Read},
+		{"rateLimit.write", s.RateLimit.Write},
+	} {
+		if l.limit.Rate < 0 {
+			invalid(l.key+".rps", "must not be negative, got %v", l.limit.Rate)
+		}
+		// A zero rps disables the limit, leaving the burst unused
+		switch {
+		case l.limit.Rate > 0 && l.limit.Burst < 1:
+			invalid(l.key+".burst", "must be at least 1, got %d", l.limit.Burst)
+		case l.limit.Burst < 0:
+			invalid(l.key+".burst", "must not be negative, got %d", l.limit.Burst)
+		}
+	}
+
+	if s.Solver.MaxPackSize < 0 {
+		invalid("solver.maxPackSize", "must not be negative, got %d", s.Solver.MaxPackSize)
+	}
+	if s.Solver.MaxItems < 0 {
+		invalid("solver.maxItems", "must not be negative, got %d", s.Solver.MaxItems)
+	}
+
+	if s.Auth.JWTHMACSecret != "" && s.Auth.JWTJWKSFile != "" {
+		invalid("auth", "only one of jwtHMACSecret and jwtJWKSFile can be set")
+	}
+
+	switch s.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterElastic, tracing.ExporterOTLP, tracing.ExporterStdout:
+	case tracing.ExporterFile:
+		if s.Tracing.File == "" {
+			invalid("tracing.file", "must be set for the %s exporter", tracing.ExporterFile)
+		}
+	default:
+		invalid("tracing.exporter", "unknown exporter %q", s.Tracing.Exporter)
+	}
+
+	for _, v := range []struct {
+		key     string
+		version APIVersionSettings
+	}{
+		{"apiVersions.v1", s.APIVersions.V1},
+		{"apiVersions.v2", s.APIVersions.V2},
+	} {
+		if !v.version.Deprecation.IsZero() && !v.version.Sunset.IsZero() && v.version.Sunset.Before(v.version.Deprecation) {
+			invalid(v.key+".sunset", "must not be before the deprecation")
+		}
+	}
+
+	// The files are read after validation, but a missing one is reported
+	// here with the rest
+	for _, f := range []struct{ key, path string }{
+		{"tls.certFile", s.TLS.CertFile},
+		{"tls.keyFile", s.TLS.KeyFile},
+		{"mongodb.certPath", s.MongoDB.CertPath},
+		{"auth.jwtJWKSFile", s.Auth.JWTJWKSFile},
+	} {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			invalid(f.key, "%s", err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,117 @@
+// Package metrics holds the Prometheus collectors of the service and the
+// handler that exposes them.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "packs"
+
+// Registry holds every collector of the service. It is used instead of the
+// default registry, so only the metrics below and the Go and process
+// metrics are exposed.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts the handled requests by route template, method and
+	// status code.
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by route template, method and status code.",
+	}, []string{"route", "method", "status"})
+	// HTTPRequestDuration observes the time taken to handle requests.
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Time taken to handle HTTP requests by route template, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+	// Panics counts the panics recovered while handling requests by route
+	// template.
+	Panics = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "panics_total",
+		Help:      "Number of panics recovered while handling HTTP requests by route template.",
+	}, []string{"route"})
+
+	// SolverDuration observes the time taken by services.GetPacks.
+	SolverDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "solver",
+		Name:      "duration_seconds",
+		Help:      "Time taken to find the packs of an order by objective.",
+		Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 10),
+	}, []string{"objective"})
+	// SolverTableSize observes the number of entries of the largest table
+	// the solver allocated for an order.
+	SolverTableSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "solver",
+		Name:      "table_size",
+		Help:      "Number of entries of the largest table allocated to find the packs of an order.",
+		Buckets:   prometheus.ExponentialBuckets(10, 10, 7),
+	})
+	// SolverOverage observes the items shipped beyond the ordered amount.
+	SolverOverage = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "solver",
+		Name:      "overage_items",
+		Help:      "Number of items shipped beyond the ordered amount.",
+		Buckets:   []float64{0, 1, 5, 10, 50, 100, 500, 1000, 5000},
+	})
+	// SolverPacks observes the number of packs shipped per order.
+	SolverPacks = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "solver",
+		Name:      "packs",
+		Help:      "Number of packs shipped per order.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+	})
+
+	// StoreOperationDuration observes the time taken by each store
+	// operation, failed or not.
+	StoreOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "operation_duration_seconds",
+		Help:      "Time taken by store operations by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+	// StoreErrors counts the store operations that failed.
+	StoreErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "errors_total",
+		Help:      "Number of failed store operations by operation.",
+	}, []string{"operation"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		Panics,
+		SolverDuration,
+		SolverTableSize,
+		SolverOverage,
+		SolverPacks,
+		StoreOperationDuration,
+		StoreErrors,
+	)
+}
+
+// Handler serves the metrics in Registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
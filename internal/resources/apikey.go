@@ -0,0 +1,18 @@
+package resources
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKey is a client allowed to call the API. Only the SHA-256 hash of the
+// key is stored, see utils.HashAPIKey. Role names the routes the key may
+// call, as for bearer tokens.
+type APIKey struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Name      string             `json:"name" bson:"name"`
+	KeyHash   string             `json:"-" bson:"key_hash"`
+	Role      string             `json:"role" bson:"role"`
+	CreatedAt time.Time          `json:"createdAt" bson:"created_at"`
+}
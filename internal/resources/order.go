@@ -9,13 +9,93 @@ import (
 type OrderRequest struct {
 	Items     int   `json:"items"`
 	PackSizes []int `json:"packSizes"`
+	// Availability optionally limits the packs in stock per pack size.
+	Availability map[int]int `json:"availability,omitempty"`
+	// Objective selects how packings are ranked, "min_overage" by default.
+	Objective  string      `json:"objective,omitempty"`
+	PackCosts  map[int]int `json:"packCosts,omitempty"`
+	MaxOverage *int        `json:"maxOverage,omitempty"`
+}
+
+// OrderPatchRequest changes an existing order. Fields that are left out keep
+// their current value, so new pack sizes of a cost order need new PackCosts.
+// An empty Availability drops the stock limits.
+type OrderPatchRequest struct {
+	Items        *int        `json:"items,omitempty"`
+	PackSizes    []int       `json:"packSizes,omitempty"`
+	Availability map[int]int `json:"availability,omitempty"`
+	PackCosts    map[int]int `json:"packCosts,omitempty"`
+}
+
+type Pack struct {
+	Size     int `json:"size" bson:"size"`
+	Quantity int `json:"quantity" bson:"quantity"`
 }
 
 type Order struct {
 	ID           primitive.ObjectID `json:"id" bson:"_id"`
 	Items        int                `json:"items" bson:"items"`
 	PackSizes    []int              `json:"packSizes" bson:"pack_sizes"`
+	Availability map[int]int        `json:"availability,omitempty" bson:"availability,omitempty"`
 	PackQuantity map[int]int        `json:"packQuantity" bson:"pack_quantity"`
+	Packs        []Pack             `json:"packs" bson:"packs"`
+	TotalPacks   int                `json:"totalPacks" bson:"total_packs"`
+	ItemsShipped int                `json:"itemsShipped" bson:"items_shipped"`
+	Overage      int                `json:"overage" bson:"overage"`
+	TotalCost    int                `json:"totalCost,omitempty" bson:"total_cost,omitempty"`
+	Objective    string             `json:"objective" bson:"objective"`
+	PackCosts    map[int]int        `json:"packCosts,omitempty" bson:"pack_costs,omitempty"`
+	MaxOverage   *int               `json:"maxOverage,omitempty" bson:"max_overage,omitempty"`
+	CreatedBy    string             `json:"createdBy,omitempty" bson:"created_by,omitempty"`
 	CreatedAt    time.Time          `json:"createdAt" bson:"created_at"`
 	UpdatedAt    time.Time          `json:"updatedAt" bson:"updated_at"`
 }
+
+type PackingSolution struct {
+	Packs        []Pack      `json:"packs"`
+	PackQuantity map[int]int `json:"packQuantity"`
+	TotalPacks   int         `json:"totalPacks"`
+	ItemsShipped int         `json:"itemsShipped"`
+	Overage      int         `json:"overage"`
+	TotalCost    int         `json:"totalCost,omitempty"`
+	Objective    string      `json:"objective"`
+}
+
+// Quote is the packing an order request would get, without the order being
+// stored. Alternatives are only filled in when asked for.
+type Quote struct {
+	Items     int   `json:"items"`
+	PackSizes []int `json:"packSizes"`
+	PackingSolution
+	Alternatives []PackingSolution `json:"alternatives,omitempty"`
+}
+
+// PackLine is a pack size of a packing as /api/v2 lists it, with the items
+// its packs hold and what they cost, zero when no costs are given.
+type PackLine struct {
+	Size     int `json:"size"`
+	Quantity int `json:"quantity"`
+	Items    int `json:"items"`
+	Cost     int `json:"cost,omitempty"`
+}
+
+// OrderV2 is an Order as /api/v2 returns it, with PackLines for its packs.
+type OrderV2 struct {
+	Order
+	Packs []PackLine `json:"packs"`
+}
+
+// PackingSolutionV2 is a PackingSolution as /api/v2 returns it, with
+// PackLines for its packs.
+type PackingSolutionV2 struct {
+	PackingSolution
+	Packs []PackLine `json:"packs"`
+}
+
+// QuoteV2 is a Quote as /api/v2 returns it.
+type QuoteV2 struct {
+	Items     int   `json:"items"`
+	PackSizes []int `json:"packSizes"`
+	PackingSolutionV2
+	Alternatives []PackingSolutionV2 `json:"alternatives,omitempty"`
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Objective names the ordering used to pick the best packing.
+type Objective string
+
+const (
+	// ObjectiveMinOverage ships as few extra items as possible, then as few
+	// packs as possible.
+	ObjectiveMinOverage Objective = "min_overage"
+	// ObjectiveMinCost ships at the lowest total pack cost, then with as few
+	// extra items and packs as possible.
+	ObjectiveMinCost Objective = "min_cost"
+	// ObjectiveMinCostMaxOverage is ObjectiveMinCost restricted to packings
+	// that ship at most the allowed overage.
+	ObjectiveMinCostMaxOverage Objective = "min_cost_max_overage"
+)
+
+// Costs is the cost of a single pack per pack size, in the smallest currency
+// unit.
+type Costs map[int]int
+
+// ParseObjective returns the objective with the given name. An empty name
+// selects ObjectiveMinOverage.
+func ParseObjective(name string) (Objective, error) {
+	switch o := Objective(name); o {
+	case "":
+		return ObjectiveMinOverage, nil
+	case ObjectiveMinOverage, ObjectiveMinCost, ObjectiveMinCostMaxOverage:
+		return o, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownObjective, name)
+	}
+}
+
+// usesCost reports whether the objective ranks packings by cost.
+func (o Objective) usesCost() bool {
+	return o == ObjectiveMinCost || o == ObjectiveMinCostMaxOverage
+}
+
+// checkCosts returns ErrCostTooLarge for the smallest pack size whose cost is
+// above MaxPackCost.
+func checkCosts(costs Costs) error {
+	sizes := make([]int, 0, len(costs))
+	for size := range costs {
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+
+	for _, size := range sizes {
+		if costs[size] > MaxPackCost {
+			return fmt.Errorf("%w of %d: %d", ErrCostTooLarge, MaxPackCost, size)
+		}
+	}
+
+	return nil
+}
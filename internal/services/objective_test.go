@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestGetPacks_WithObjective(t *testing.T) {
+	tests := []struct {
+		name        string
+		orderAmount int
+		packSizes   []int
+		opts        []Option
+		want        map[int]int
+		wantCost    int
+	}{
+		{
+			"min overage ignores cost", 1000, []int{250, 500, 1000},
+			[]Option{WithCosts(Costs{250: 100, 500: 150, 1000: 400})},
+			map[int]int{1000: 1}, 400,
+		},
+		{
+			"min cost", 1000, []int{250, 500, 1000},
+			[]Option{WithObjective(ObjectiveMinCost), WithCosts(Costs{250: 100, 500: 150, 1000: 400})},
+			map[int]int{500: 2}, 300,
+		},
+		{
+			"min cost accepts more overage", 501, []int{250, 500, 1000},
+			[]Option{WithObjective(ObjectiveMinCost), WithCosts(Costs{250: 100, 500: 150, 1000: 200})},
+			map[int]int{1000: 1}, 200,
+		},
+		{
+			"min cost within overage", 501, []int{250, 500, 1000},
+			[]Option{WithObjective(ObjectiveMinCostMaxOverage), WithCosts(Costs{250: 100, 500: 150, 1000: 200}), WithMaxOverage(300)},
+			map[int]int{250: 1, 500: 1}, 250,
+		},
+		{
+			"max overage of max int", 1000, []int{250, 500, 1000},
+			[]Option{WithObjective(ObjectiveMinCostMaxOverage), WithCosts(Costs{250: 100, 500: 150, 1000: 400}), WithMaxOverage(math.MaxInt)},
+			map[int]int{500: 2}, 300,
+		},
+		{
+			"min cost with stock", 1000, []int{250, 500, 1000},
+			[]Option{WithObjective(ObjectiveMinCost), WithCosts(Costs{250: 100, 500: 150, 1000: 400}), WithAvailability(Availability{500: 1})},
+			map[int]int{250: 2, 500: 1}, 350,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := GetPacks(tt.orderAmount, tt.packSizes, tt.opts...)
+			if err != nil {
+				t.Fatalf("GetPacks() error = %v", err)
+			}
+			if got := res.PackQuantity(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetPacks() = %v, want %v", got, tt.want)
+			}
+			if res.TotalCost != tt.wantCost {
+				t.Errorf("GetPacks() cost = %d, want %d", res.TotalCost, tt.wantCost)
+			}
+		})
+	}
+}
+
+func TestGetPacks_OverageNotAllowed(t *testing.T) {
+	_, err := GetPacks(501, []int{250, 500}, WithObjective(ObjectiveMinCostMaxOverage), WithCosts(Costs{250: 1, 500: 1}), WithMaxOverage(0))
+	if !errors.Is(err, ErrOverageNotAllowed) {
+		t.Errorf("GetPacks() error = %v, want %v", err, ErrOverageNotAllowed)
+	}
+}
+
+func TestGetPacks_CostTooLarge(t *testing.T) {
+	_, err := GetPacks(2, []int{1, 2}, WithObjective(ObjectiveMinCost), WithCosts(Costs{1: 5e18, 2: 9e18}))
+	if !errors.Is(err, ErrCostTooLarge) {
+		t.Errorf("GetPacks() error = %v, want %v", err, ErrCostTooLarge)
+	}
+
+	_, err = GetTopPacks(2, []int{1, 2}, 3, WithObjective(ObjectiveMinCost), WithCosts(Costs{1: 5e18, 2: 9e18}))
+	if !errors.Is(err, ErrCostTooLarge) {
+		t.Errorf("GetTopPacks() error = %v, want %v", err, ErrCostTooLarge)
+	}
+}
+
+func TestGetPacks_MinCostMatchesBruteForce(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		sizes := rnd.Perm(30)[:1+rnd.Intn(3)]
+		costs := make(Costs)
+		for j := range sizes {
+			sizes[j]++
+			costs[sizes[j]] = rnd.Intn(50)
+		}
+		N := 1 + rnd.Intn(150)
+
+		res, err := GetPacks(N, sizes, WithObjective(ObjectiveMinCost), WithCosts(costs))
+		if err != nil {
+			t.Fatalf("GetPacks(%d, %v, %v) error = %v", N, sizes, costs, err)
+		}
+
+		wantCost, wantOverage, wantPacks := bruteForceMinCost(N, sizes, costs)
+		if res.TotalCost != wantCost || res.Overage != wantOverage || res.TotalPacks != wantPacks {
+			t.Fatalf("GetPacks(%d, %v, %v) = %+v, want cost %d, overage %d, packs %d",
+				N, sizes, costs, res, wantCost, wantOverage, wantPacks)
+		}
+	}
+}
+
+// bruteForceMinCost returns the lowest cost of covering N, then the lowest
+// overage and pack count at that cost.
+func bruteForceMinCost(N int, sizes []int, costs Costs) (cost, overage, packs int) {
+	cost = -1
+
+	var walk func(i, sum, c, n int)
+	walk = func(i, sum, c, n int) {
+		if i == len(sizes) {
+			if sum < N {
+				return
+			}
+			o := sum - N
+			if cost == -1 || c < cost || (c == cost && (o < overage || (o == overage && n < packs))) {
+				cost, overage, packs = c, o, n
+			}
+			return
+		}
+		for k := 0; sum+k*sizes[i] < N+30; k++ {
+			walk(i+1, sum+k*sizes[i], c+k*costs[sizes[i]], n+k)
+		}
+	}
+	walk(0, 0, 0, 0)
+
+	return cost, overage, packs
+}
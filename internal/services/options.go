@@ -0,0 +1,50 @@
+package services
+
+// Option configures a call to GetPacks.
+type Option func(*options)
+
+type options struct {
+	availability Availability
+	objective    Objective
+	costs        Costs
+	maxOverage   int
+}
+
+// WithAvailability limits the packs GetPacks may use to the given stock.
+func WithAvailability(availability Availability) Option {
+	return func(o *options) {
+		o.availability = availability
+	}
+}
+
+// WithObjective selects how packings are ranked. The default is
+// ObjectiveMinOverage.
+func WithObjective(objective Objective) Option {
+	return func(o *options) {
+		o.objective = objective
+	}
+}
+
+// WithCosts sets the cost of a single pack per pack size. Sizes without a cost
+// are free.
+func WithCosts(costs Costs) Option {
+	return func(o *options) {
+		o.costs = costs
+	}
+}
+
+// WithMaxOverage sets the most extra items ObjectiveMinCostMaxOverage may
+// ship.
+func WithMaxOverage(maxOverage int) Option {
+	return func(o *options) {
+		o.maxOverage = maxOverage
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{objective: ObjectiveMinOverage}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
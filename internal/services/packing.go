@@ -1,6 +1,12 @@
 package services
 
-import "math"
+import (
+	"math"
+	"sort"
+	"time"
+
+	"packs-api/internal/metrics"
+)
 
 type dpEntry struct {
 	count int
@@ -9,13 +15,88 @@ type dpEntry struct {
 }
 
 // GetPacks calculates the minimal number of packs to achieve a certain amount
-// and returns the count of each pack size.
-func GetPacks(N int, packSizes []int) map[int]int {
+// and returns the count of each pack size. It returns ErrNoPackSizes when no
+// positive pack size is given and ErrNoSolution when no packing exists.
+//
+// Memory depends on the pack sizes only, not on N: the smallest shippable
+// amount is found over residues modulo the smallest pack and the fewest packs
+// for it over residues modulo the largest pack. Cost objectives and stock
+// limits that can run out before N is covered need a table over
+// [0, N+largest] instead, see solveTable.
+func GetPacks(N int, packSizes []int, opts ...Option) (*PackingResult, error) {
+	o := newOptions(opts)
+
+	start := time.Now()
+	result, tableSize, err := getPacks(N, packSizes, o)
+	observeSolve(o, time.Since(start), tableSize, result)
+
+	return result, err
+}
+
+// getPacks is GetPacks, also returning the number of entries of the largest
+// table it allocated.
+func getPacks(N int, packSizes []int, o *options) (*PackingResult, int, error) {
+	sizes := normalizePackSizes(packSizes)
+	if len(sizes) == 0 {
+		return nil, 0, ErrNoPackSizes
+	}
+	// The solvers look at amounts up to N plus twice the largest pack
+	if overflows(N, 2, sizes[len(sizes)-1]) {
+		return nil, 0, ErrOrderTooLarge
+	}
+
+	if err := checkCosts(o.costs); err != nil {
+		return nil, 0, err
+	}
+
+	if o.objective.usesCost() || o.availability.limits(N, sizes) {
+		packCount, tableSize, err := solveTable(N, sizes, o)
+		if err != nil {
+			return nil, tableSize, err
+		}
+		return newPackingResult(N, packCount, o), tableSize, nil
+	}
+
+	target := findTarget(N, sizes)
+	if target == -1 {
+		return nil, sizes[0], ErrNoSolution
+	}
+
+	packCount, tableSize, err := minimalPacks(target, sizes)
+	tableSize = max(tableSize, sizes[0])
+	if err != nil {
+		return nil, tableSize, err
+	}
+	if packCount == nil {
+		return nil, tableSize, ErrNoSolution
+	}
+
+	return newPackingResult(N, packCount, o), tableSize, nil
+}
+
+// observeSolve records a GetPacks call in the solver metrics. The overage
+// and pack count are only known when a packing was found.
+func observeSolve(o *options, duration time.Duration, tableSize int, result *PackingResult) {
+	metrics.SolverDuration.WithLabelValues(string(o.objective)).Observe(duration.Seconds())
+	if tableSize > 0 {
+		metrics.SolverTableSize.Observe(float64(tableSize))
+	}
+	if result != nil {
+		metrics.SolverOverage.Observe(float64(result.Overage))
+		metrics.SolverPacks.Observe(float64(result.TotalPacks))
+	}
+}
+
+// getPacksDP is the original DP solver. Its table has N plus the largest pack
+// entries, so it is only suitable for small orders and as a reference in tests.
+func getPacksDP(N int, packSizes []int) map[int]int {
 	if len(packSizes) == 0 {
 		return nil
 	}
 
-	maxCheck := N + packSizes[0]
+	// The best sum is below N plus the largest pack, since dropping the last
+	// pack of any bigger sum still covers N
+	maxCheck := N + maxPackSize(packSizes)
 	dp := initializeDP(maxCheck)
 
 	// Fill DP table with minimal pack counts
@@ -32,6 +113,38 @@ func GetPacks(N int, packSizes []int) map[int]int {
 	return reconstructSolution(dp, bestSum)
 }
 
+// overflows reports whether N plus n packs of size p is larger than an int
+// can hold.
+func overflows(N, n, p int) bool {
+	return N > 0 && (math.MaxInt-N)/p < n
+}
+
+func maxPackSize(packSizes []int) int {
+	largest := packSizes[0]
+	for _, p := range packSizes[1:] {
+		if p > largest {
+			largest = p
+		}
+	}
+	return largest
+}
+
+// normalizePackSizes returns the distinct positive pack sizes sorted ascending.
+func normalizePackSizes(packSizes []int) []int {
+	sizes := make([]int, 0, len(packSizes))
+	seen := make(map[int]bool, len(packSizes))
+	for _, p := range packSizes {
+		if p <= 0 || seen[p] {
+			continue
+		}
+		seen[p] = true
+		sizes = append(sizes, p)
+	}
+	sort.Ints(sizes)
+
+	return sizes
+}
+
 // initializeDP initializes the dp array to store pack counts, with a default of -1 for all values.
 func initializeDP(maxCheck int) []dpEntry {
 	dp := make([]dpEntry, maxCheck+1)
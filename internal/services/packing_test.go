@@ -1,6 +1,14 @@
 package services
 
-import "testing"
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"packs-api/internal/resources"
+)
 
 func TestGetPacks(t *testing.T) {
 
@@ -20,7 +28,11 @@ func TestGetPacks(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := GetPacks(tt.orderAmount, tt.packSizes)
+			res, err := GetPacks(tt.orderAmount, tt.packSizes)
+			if err != nil {
+				t.Fatalf("GetPacks() error = %v", err)
+			}
+			got := res.PackQuantity()
 			if len(got) != len(tt.want) {
 				t.Errorf("GetPacks() = %v, want %v", got, tt.want)
 			}
@@ -32,3 +44,171 @@ func TestGetPacks(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPacks_MatchesDP(t *testing.T) {
+	type input struct {
+		orderAmount int
+		packSizes   []int
+	}
+
+	inputs := []input{
+		{0, []int{1, 2, 3}},
+		{201, []int{250, 500, 1000, 2000, 5000}},
+		{251, []int{250, 500, 1000, 2000, 5000}},
+		{501, []int{250, 500, 1000, 2000, 5000}},
+		{12001, []int{250, 500, 1000, 2000, 5000}},
+		{500000, []int{23, 31, 53}},
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		sizes := rnd.Perm(60)[:1+rnd.Intn(4)]
+		for j := range sizes {
+			sizes[j]++
+		}
+		inputs = append(inputs, input{rnd.Intn(2000), sizes})
+	}
+	// Targets below the square of the largest pack, where the cheapest
+	// remainder can overshoot
+	for i := 0; i < 100; i++ {
+		sizes := rnd.Perm(300)[:2+rnd.Intn(4)]
+		for j := range sizes {
+			sizes[j]++
+		}
+		largest := maxPackSize(sizes)
+		inputs = append(inputs, input{rnd.Intn(largest * largest), sizes})
+	}
+
+	for _, in := range inputs {
+		got, err := GetPacks(in.orderAmount, in.packSizes)
+		if err != nil {
+			t.Fatalf("GetPacks(%d, %v) error = %v", in.orderAmount, in.packSizes, err)
+		}
+		want := getPacksDP(in.orderAmount, in.packSizes)
+
+		wantTotal, wantCount := packTotals(want)
+		if got.ItemsShipped != wantTotal || got.TotalPacks != wantCount {
+			t.Fatalf("GetPacks(%d, %v) = %v, DP = %v", in.orderAmount, in.packSizes, got.PackQuantity(), want)
+		}
+	}
+}
+
+func TestGetPacks_LargeOrder(t *testing.T) {
+	res, err := GetPacks(1_000_000_001, []int{250, 500, 1000, 2000, 5000})
+	if err != nil {
+		t.Fatalf("GetPacks() error = %v", err)
+	}
+	got := res.PackQuantity()
+	want := map[int]int{5000: 200000, 250: 1}
+	if len(got) != len(want) {
+		t.Fatalf("GetPacks() = %v, want %v", got, want)
+	}
+	for k, v := range got {
+		if want[k] != v {
+			t.Fatalf("GetPacks() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestGetPacks_NearMaxInt(t *testing.T) {
+	tests := []struct {
+		name      string
+		items     int
+		packSizes []int
+		opts      []Option
+		wantErr   error
+	}{
+		{"max int", math.MaxInt, []int{250, 500}, nil, ErrOrderTooLarge},
+		{"just below max int", math.MaxInt - 10, []int{250, 500}, nil, ErrOrderTooLarge},
+		{"cost", math.MaxInt - 10, []int{250, 500}, []Option{WithObjective(ObjectiveMinCost), WithCosts(Costs{250: 1, 500: 2})}, ErrOrderTooLarge},
+		{"fits", math.MaxInt - 1000, []int{250, 500}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := GetPacks(tt.items, tt.packSizes, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetPacks() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (res.ItemsShipped < tt.items || res.Overage != res.ItemsShipped-tt.items) {
+				t.Errorf("GetPacks() = %+v, does not cover %d items", res, tt.items)
+			}
+
+			_, err = GetTopPacks(tt.items, tt.packSizes, 5, tt.opts...)
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetTopPacks() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func packTotals(packs map[int]int) (total, count int) {
+	for size, n := range packs {
+		total += size * n
+		count += n
+	}
+	return total, count
+}
+
+func TestGetPacks_OvershootingRemainder(t *testing.T) {
+	res, err := GetPacks(99_890_009, []int{3, 9999, 10000})
+	if err != nil {
+		t.Fatalf("GetPacks() error = %v", err)
+	}
+	got := res.PackQuantity()
+	want := map[int]int{3: 3, 10000: 9989}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPacks() = %v, want %v", got, want)
+	}
+}
+
+func TestGetPacks_Result(t *testing.T) {
+	got, err := GetPacks(501, []int{1000, 250, 500})
+	if err != nil {
+		t.Fatalf("GetPacks() error = %v", err)
+	}
+
+	want := &PackingResult{
+		Packs:        []resources.Pack{{Size: 250, Quantity: 1}, {Size: 500, Quantity: 1}},
+		TotalPacks:   2,
+		ItemsShipped: 750,
+		Overage:      249,
+		Objective:    ObjectiveMinOverage,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPacks() = %+v, want %+v", got, want)
+	}
+
+	if _, err := GetPacks(10, nil); !errors.Is(err, ErrNoPackSizes) {
+		t.Errorf("GetPacks() error = %v, want %v", err, ErrNoPackSizes)
+	}
+}
+
+func TestGetPacks_TableSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		orderAmount   int
+		packSizes     []int
+		opts          []Option
+		wantTableSize int
+	}{
+		// Residues modulo the largest pack
+		{"residues", 500000, []int{23, 31, 53}, nil, 53},
+		// The cheapest remainder, 9991 packs of 9999, overshoots, so the
+		// search is bounded to remainders within the target
+		{"small target", 99_890_009, []int{3, 9999, 10000}, nil, 19997},
+		{"cost", 12001, []int{250, 500, 1000, 2000, 5000}, []Option{WithObjective(ObjectiveMinCost)}, 17001},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, tableSize, err := getPacks(tt.orderAmount, tt.packSizes, newOptions(tt.opts))
+			if err != nil {
+				t.Fatalf("getPacks() error = %v", err)
+			}
+			if tableSize != tt.wantTableSize {
+				t.Errorf("getPacks() table size = %d, want %d", tableSize, tt.wantTableSize)
+			}
+		})
+	}
+}
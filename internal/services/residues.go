@@ -0,0 +1,252 @@
+package services
+
+import (
+	"container/heap"
+	"math"
+)
+
+// residueEntry is the best known way to reach one residue class.
+type residueEntry struct {
+	weight int
+	sum    int
+	pack   int
+}
+
+// shortestResidues runs Dijkstra over the residues modulo mod, where adding a
+// pack p moves from r to (r+p)%mod at a cost of weight(p). Ties are broken by
+// the smallest sum. Unreachable residues keep a weight of -1.
+func shortestResidues(mod int, packSizes []int, weight func(p int) int) []residueEntry {
+	entries := make([]residueEntry, mod)
+	for i := range entries {
+		entries[i].weight = -1
+	}
+	entries[0].weight = 0
+
+	pq := &residueQueue{{residue: 0}}
+	for pq.Len() > 0 {
+		item := heap.Pop(pq).(residueItem)
+		current := entries[item.residue]
+		if item.weight != current.weight || item.sum != current.sum {
+			continue
+		}
+
+		for _, p := range packSizes {
+			next := (item.residue + p) % mod
+			w := item.weight + weight(p)
+			sum := item.sum + p
+			e := entries[next]
+			if e.weight == -1 || w < e.weight || (w == e.weight && sum < e.sum) {
+				entries[next] = residueEntry{weight: w, sum: sum, pack: p}
+				heap.Push(pq, residueItem{residue: next, weight: w, sum: sum})
+			}
+		}
+	}
+
+	return entries
+}
+
+// findTarget returns the smallest amount of at least N that can be built from
+// packSizes, or -1 if there is none. packSizes must be sorted ascending.
+func findTarget(N int, packSizes []int) int {
+	if N <= 0 {
+		return 0
+	}
+
+	smallest := packSizes[0]
+	entries := shortestResidues(smallest, packSizes, func(p int) int { return p })
+
+	target := math.MaxInt
+	for _, e := range entries {
+		if e.weight == -1 {
+			continue
+		}
+		// Every amount e.sum + k*smallest is reachable as well
+		sum := e.sum
+		if sum < N {
+			sum += (N - sum + smallest - 1) / smallest * smallest
+		}
+		if sum < target {
+			target = sum
+		}
+	}
+
+	if target == math.MaxInt {
+		return -1
+	}
+
+	return target
+}
+
+// minimalPacks returns the pack counts that add up to exactly target using
+// as few packs as possible, and the number of entries of the table it used.
+// packSizes must be sorted ascending. It returns ErrOrderTooLarge when the
+// search for a small target outgrows maxResidueLabels.
+//
+// Writing target as the largest pack times k plus the remaining packs, the
+// pack count is target/largest plus the sum of (largest-p)/largest over the
+// remaining packs, so the cheapest remainder for target%largest is a shortest
+// path over residues modulo the largest pack.
+func minimalPacks(target int, packSizes []int) (map[int]int, int, error) {
+	largest := packSizes[len(packSizes)-1]
+	entries := shortestResidues(largest, packSizes, func(p int) int { return largest - p })
+
+	e := entries[target%largest]
+	if e.weight == -1 {
+		return nil, largest, nil
+	}
+
+	// The cheapest remainder overshoots a small target, so search again for
+	// the cheapest remainder that fits
+	if e.sum > target {
+		packCount, labels, err := boundedMinimalPacks(target, packSizes)
+		return packCount, largest + labels, err
+	}
+
+	packCount := make(map[int]int)
+	current := target % largest
+	for current != 0 {
+		p := entries[current].pack
+		packCount[p]++
+		current = ((current-p)%largest + largest) % largest
+	}
+	if k := (target - e.sum) / largest; k > 0 {
+		packCount[largest] += k
+	}
+
+	return packCount, largest, nil
+}
+
+// maxResidueLabels caps the labels boundedMinimalPacks keeps in memory.
+const maxResidueLabels = 1_000_000
+
+// residueLabel is a way of reaching a residue modulo the largest pack with
+// remaining packs that sum to sum. parent is the label it extends, -1 for
+// the empty remainder.
+type residueLabel struct {
+	residue int
+	weight  int
+	sum     int
+	pack    int
+	parent  int32
+}
+
+// boundedMinimalPacks is minimalPacks for a target that the cheapest
+// remainder overshoots. It runs the same shortest path search, but over
+// remainders that sum to at most target, and returns the number of labels
+// it kept along with the pack counts.
+//
+// Labels are taken in order of weight and then sum, so a label is only kept
+// when its sum is below that of every label kept before at its residue,
+// which reach it at no higher weight. Any remainder built on the dropped
+// label is matched by one built on the kept label that weighs and sums no
+// more.
+func boundedMinimalPacks(target int, packSizes []int) (map[int]int, int, error) {
+	largest := packSizes[len(packSizes)-1]
+	remaining := packSizes[:len(packSizes)-1]
+
+	minSum := make([]int, largest)
+	for i := range minSum {
+		minSum[i] = math.MaxInt
+	}
+
+	var labels []residueLabel
+	pq := &labelQueue{{parent: -1}}
+	found := -1
+	for pq.Len() > 0 {
+		l := heap.Pop(pq).(residueLabel)
+		if l.sum >= minSum[l.residue] {
+			continue
+		}
+		if len(labels) == maxResidueLabels {
+			return nil, len(labels), ErrOrderTooLarge
+		}
+		minSum[l.residue] = l.sum
+		labels = append(labels, l)
+		if l.residue == target%largest {
+			found = len(labels) - 1
+			break
+		}
+
+		for _, p := range remaining {
+			next := residueLabel{
+				residue: (l.residue + p) % largest,
+				weight:  l.weight + largest - p,
+				sum:     l.sum + p,
+				pack:    p,
+				parent:  int32(len(labels) - 1),
+			}
+			if next.sum <= target && next.sum < minSum[next.residue] {
+				heap.Push(pq, next)
+			}
+		}
+	}
+
+	if found == -1 {
+		return nil, len(labels), nil
+	}
+
+	packCount := make(map[int]int)
+	for idx := int32(found); labels[idx].parent != -1; idx = labels[idx].parent {
+		packCount[labels[idx].pack]++
+	}
+	if k := (target - labels[found].sum) / largest; k > 0 {
+		packCount[largest] += k
+	}
+
+	return packCount, len(labels), nil
+}
+
+// labelQueue is a min-heap of residue labels ordered by weight and then by
+// sum.
+type labelQueue []residueLabel
+
+func (q labelQueue) Len() int { return len(q) }
+
+func (q labelQueue) Less(i, j int) bool {
+	if q[i].weight != q[j].weight {
+		return q[i].weight < q[j].weight
+	}
+	return q[i].sum < q[j].sum
+}
+
+func (q labelQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *labelQueue) Push(x any) { *q = append(*q, x.(residueLabel)) }
+
+func (q *labelQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+type residueItem struct {
+	residue int
+	weight  int
+	sum     int
+}
+
+// residueQueue is a min-heap of residues ordered by weight and then by sum.
+type residueQueue []residueItem
+
+func (q residueQueue) Len() int { return len(q) }
+
+func (q residueQueue) Less(i, j int) bool {
+	if q[i].weight != q[j].weight {
+		return q[i].weight < q[j].weight
+	}
+	return q[i].sum < q[j].sum
+}
+
+func (q residueQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *residueQueue) Push(x any) { *q = append(*q, x.(residueItem)) }
+
+func (q *residueQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"errors"
+	"sort"
+
+	"packs-api/internal/resources"
+)
+
+var (
+	ErrNoSolution        = errors.New("no combination of pack sizes covers the order")
+	ErrOverageNotAllowed = errors.New("no combination of pack sizes covers the order within the allowed overage")
+	ErrOrderTooLarge     = errors.New("order is too large for the requested packing options")
+)
+
+// PackingResult describes the packs chosen for an order.
+type PackingResult struct {
+	// Packs holds the quantity of every pack size used, sorted by size.
+	Packs        []resources.Pack
+	TotalPacks   int
+	ItemsShipped int
+	Overage      int
+	// TotalCost is the sum of the pack costs, zero when no costs are given.
+	TotalCost int
+	Objective Objective
+}
+
+// PackQuantity returns the quantity of each pack size keyed by size.
+func (r *PackingResult) PackQuantity() map[int]int {
+	packQuantity := make(map[int]int, len(r.Packs))
+	for _, p := range r.Packs {
+		packQuantity[p.Size] = p.Quantity
+	}
+	return packQuantity
+}
+
+func newPackingResult(N int, packCount map[int]int, o *options) *PackingResult {
+	result := &PackingResult{
+		Packs:     make([]resources.Pack, 0, len(packCount)),
+		Objective: o.objective,
+	}
+
+	for size, quantity := range packCount {
+		result.Packs = append(result.Packs, resources.Pack{Size: size, Quantity: quantity})
+		result.TotalPacks += quantity
+		result.ItemsShipped += size * quantity
+		result.TotalCost += o.costs[size] * quantity
+	}
+	sort.Slice(result.Packs, func(i, j int) bool {
+		return result.Packs[i].Size < result.Packs[j].Size
+	})
+
+	if result.ItemsShipped > N {
+		result.Overage = result.ItemsShipped - N
+	}
+
+	return result
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"packs-api/internal/resources"
+)
+
+// Availability is the number of packs in stock per pack size. Sizes that are
+// not listed are unlimited.
+type Availability map[int]int
+
+// InsufficientStockError is returned when the packs in stock cannot cover the
+// order at all.
+type InsufficientStockError struct {
+	Items    int
+	Capacity int
+	// Shortfall lists the packs of the best unlimited packing that are
+	// missing from stock.
+	Shortfall []resources.Pack
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock: available packs hold %d of %d items", e.Capacity, e.Items)
+}
+
+// limits reports whether the stock of any size can run out. No optimal
+// packing ships N plus the largest pack or more, so a size with enough stock
+// to reach that on its own behaves as unlimited.
+func (a Availability) limits(N int, sizes []int) bool {
+	limits, _ := a.stockLimits(N+sizes[len(sizes)-1], sizes)
+	return len(limits) > 0
+}
+
+// stockLimits returns the packs in stock for every size that cannot reach
+// bound on its own, and the number of items those packs hold together.
+func (a Availability) stockLimits(bound int, sizes []int) (map[int]int, int) {
+	limits := make(map[int]int)
+	capacity := 0
+	for _, p := range sizes {
+		if n, ok := a[p]; ok && n < (bound+p-1)/p {
+			limits[p] = n
+			capacity += n * p
+		}
+	}
+	return limits, capacity
+}
+
+func newInsufficientStockError(N int, sizes []int, availability Availability, capacity int) *InsufficientStockError {
+	e := &InsufficientStockError{Items: N, Capacity: capacity}
+
+	// The shortfall is left out if the search is too large
+	packCount, _, _ := minimalPacks(findTarget(N, sizes), sizes)
+	for size, quantity := range packCount {
+		if n := availability[size]; quantity > n {
+			e.Shortfall = append(e.Shortfall, resources.Pack{Size: size, Quantity: quantity - n})
+		}
+	}
+	sort.Slice(e.Shortfall, func(i, j int) bool {
+		return e.Shortfall[i].Size < e.Shortfall[j].Size
+	})
+
+	return e
+}
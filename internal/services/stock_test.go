@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"packs-api/internal/resources"
+)
+
+func TestGetPacks_WithAvailability(t *testing.T) {
+	tests := []struct {
+		name         string
+		orderAmount  int
+		packSizes    []int
+		availability Availability
+		want         map[int]int
+	}{
+		{"enough stock", 501, []int{250, 500, 1000}, Availability{250: 10, 500: 10}, map[int]int{250: 1, 500: 1}},
+		{"out of 500", 501, []int{250, 500, 1000}, Availability{500: 0}, map[int]int{250: 3}},
+		{"out of 250 and 500", 501, []int{250, 500, 1000}, Availability{250: 0, 500: 0}, map[int]int{1000: 1}},
+		{"limited 5000", 12001, []int{250, 500, 1000, 2000, 5000}, Availability{5000: 1}, map[int]int{5000: 1, 2000: 3, 1000: 1, 250: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := GetPacks(tt.orderAmount, tt.packSizes, WithAvailability(tt.availability))
+			if err != nil {
+				t.Fatalf("GetPacks() error = %v", err)
+			}
+			if got := res.PackQuantity(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetPacks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPacks_InsufficientStock(t *testing.T) {
+	_, err := GetPacks(501, []int{250, 500, 1000}, WithAvailability(Availability{250: 1, 500: 0, 1000: 0}))
+
+	var stockErr *InsufficientStockError
+	if !errors.As(err, &stockErr) {
+		t.Fatalf("GetPacks() error = %v, want InsufficientStockError", err)
+	}
+
+	want := &InsufficientStockError{
+		Items:     501,
+		Capacity:  250,
+		Shortfall: []resources.Pack{{Size: 500, Quantity: 1}},
+	}
+	if !reflect.DeepEqual(stockErr, want) {
+		t.Errorf("GetPacks() error = %+v, want %+v", stockErr, want)
+	}
+}
+
+func TestGetPacks_WithAvailabilityMatchesBruteForce(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		sizes := rnd.Perm(30)[:1+rnd.Intn(3)]
+		availability := make(Availability)
+		for j := range sizes {
+			sizes[j]++
+			if rnd.Intn(3) > 0 {
+				availability[sizes[j]] = rnd.Intn(6)
+			}
+		}
+		N := 1 + rnd.Intn(150)
+
+		wantTotal, wantCount := bruteForceWithStock(N, sizes, availability)
+		res, err := GetPacks(N, sizes, WithAvailability(availability))
+		if wantTotal == -1 {
+			var stockErr *InsufficientStockError
+			if !errors.As(err, &stockErr) {
+				t.Fatalf("GetPacks(%d, %v, %v) error = %v, want InsufficientStockError", N, sizes, availability, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("GetPacks(%d, %v, %v) error = %v", N, sizes, availability, err)
+		}
+
+		for size, quantity := range res.PackQuantity() {
+			if n, ok := availability[size]; ok && quantity > n {
+				t.Fatalf("GetPacks(%d, %v, %v) = %v uses more than in stock", N, sizes, availability, res.PackQuantity())
+			}
+		}
+		if res.ItemsShipped != wantTotal || res.TotalPacks != wantCount {
+			t.Fatalf("GetPacks(%d, %v, %v) = %v, want %d items in %d packs", N, sizes, availability, res.PackQuantity(), wantTotal, wantCount)
+		}
+	}
+}
+
+// bruteForceWithStock returns the smallest total of at least N and its fewest
+// packs, or -1 if the stock cannot cover N.
+func bruteForceWithStock(N int, sizes []int, availability Availability) (total, count int) {
+	total, count = -1, -1
+
+	var walk func(i, sum, packs int)
+	walk = func(i, sum, packs int) {
+		if sum >= N || i == len(sizes) {
+			if sum >= N && (total == -1 || sum < total || (sum == total && packs < count)) {
+				total, count = sum, packs
+			}
+			return
+		}
+		limit := (N + sizes[i] - 1 - sum) / sizes[i]
+		if n, ok := availability[sizes[i]]; ok && n < limit {
+			limit = n
+		}
+		for k := 0; k <= limit; k++ {
+			walk(i+1, sum+k*sizes[i], packs+k)
+		}
+	}
+	walk(0, 0, 0)
+
+	return total, count
+}
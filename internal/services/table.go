@@ -0,0 +1,154 @@
+package services
+
+// maxTableSize caps the amounts a table based solver keeps in memory.
+const maxTableSize = 10_000_000
+
+// tableEntry is the best known way to ship exactly one amount, ranked by cost
+// and then by pack count. Unreachable amounts have packs set to -1.
+type tableEntry struct {
+	cost  int
+	packs int32
+}
+
+func (e tableEntry) less(other tableEntry) bool {
+	if other.packs == -1 {
+		return true
+	}
+	if e.cost != other.cost {
+		return e.cost < other.cost
+	}
+	return e.packs < other.packs
+}
+
+// stockChunk is a group of packs of one size that is used all or nothing.
+type stockChunk struct {
+	size     int
+	quantity int
+}
+
+// solveTable returns the best packing for the objective in o, using no more
+// packs of each size than are available, and the number of entries of its
+// table.
+//
+// Every amount in [0, N+largest) gets its cheapest packing, so memory grows
+// with N and larger orders fail with ErrOrderTooLarge. Limited sizes are split into chunks of 1, 2, 4, ... packs and solved
+// as a 0/1 knapsack, keeping one bit per chunk and amount to rebuild the
+// solution. Unlimited sizes are then added on top of the same table.
+func solveTable(N int, sizes []int, o *options) (map[int]int, int, error) {
+	if N < 0 {
+		N = 0
+	}
+	// No best packing ships N plus the largest pack or more, since dropping
+	// its last pack still covers N at no higher cost
+	bound := N + sizes[len(sizes)-1]
+
+	limits, capacity := o.availability.stockLimits(bound, sizes)
+
+	var unlimited []int
+	var chunks []stockChunk
+	for _, p := range sizes {
+		n, ok := limits[p]
+		if !ok {
+			unlimited = append(unlimited, p)
+			continue
+		}
+		for k := 1; n > 0; k *= 2 {
+			q := min(k, n)
+			chunks = append(chunks, stockChunk{size: p, quantity: q})
+			n -= q
+		}
+	}
+
+	if len(unlimited) == 0 && capacity < N {
+		return nil, 0, newInsufficientStockError(N, sizes, o.availability, capacity)
+	}
+
+	maxCheck := bound - 1
+	if len(unlimited) == 0 && capacity < maxCheck {
+		maxCheck = capacity
+	}
+	// maxCheck is at least N here, so comparing the overage cannot overflow
+	if o.objective == ObjectiveMinCostMaxOverage && o.maxOverage < maxCheck-N {
+		maxCheck = N + o.maxOverage
+	}
+	if maxCheck >= maxTableSize {
+		return nil, 0, ErrOrderTooLarge
+	}
+
+	cost := func(p int) int { return 0 }
+	if o.objective.usesCost() {
+		cost = func(p int) int { return o.costs[p] }
+	}
+
+	table := make([]tableEntry, maxCheck+1)
+	for x := 1; x <= maxCheck; x++ {
+		table[x].packs = -1
+	}
+
+	taken := make([][]uint64, len(chunks))
+	for j, c := range chunks {
+		taken[j] = make([]uint64, maxCheck/64+1)
+		w := c.size * c.quantity
+		for x := maxCheck; x >= w; x-- {
+			prev := table[x-w]
+			if prev.packs == -1 {
+				continue
+			}
+			next := tableEntry{cost: prev.cost + cost(c.size)*c.quantity, packs: prev.packs + int32(c.quantity)}
+			if next.less(table[x]) {
+				table[x] = next
+				taken[j][x/64] |= 1 << (x % 64)
+			}
+		}
+	}
+
+	// via holds the unlimited pack last added to reach an amount, or 0 when
+	// the amount is made of chunks only
+	via := make([]int32, maxCheck+1)
+	for _, p := range unlimited {
+		for x := p; x <= maxCheck; x++ {
+			prev := table[x-p]
+			if prev.packs == -1 {
+				continue
+			}
+			next := tableEntry{cost: prev.cost + cost(p), packs: prev.packs + 1}
+			if next.less(table[x]) {
+				table[x] = next
+				via[x] = int32(p)
+			}
+		}
+	}
+
+	// Amounts are scanned upwards, so a later amount only wins on cost
+	best := -1
+	for x := N; x <= maxCheck; x++ {
+		if table[x].packs == -1 {
+			continue
+		}
+		if best == -1 || table[x].cost < table[best].cost {
+			best = x
+		}
+	}
+	if best == -1 {
+		if o.objective == ObjectiveMinCostMaxOverage {
+			return nil, maxCheck + 1, ErrOverageNotAllowed
+		}
+		return nil, maxCheck + 1, ErrNoSolution
+	}
+
+	packCount := make(map[int]int)
+	current := best
+	for via[current] != 0 {
+		p := int(via[current])
+		packCount[p]++
+		current -= p
+	}
+	for j := len(chunks) - 1; j >= 0; j-- {
+		if taken[j][current/64]&(1<<(current%64)) != 0 {
+			packCount[chunks[j].size] += chunks[j].quantity
+			current -= chunks[j].size * chunks[j].quantity
+		}
+	}
+
+	return packCount, maxCheck + 1, nil
+}
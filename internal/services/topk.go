@@ -0,0 +1,257 @@
+package services
+
+import "sort"
+
+// maxTopNodes caps the nodes GetTopPacks keeps in memory. The lists lead to
+// at most half of them, which leaves room for the nodes pushed out between
+// compactions.
+const maxTopNodes = 3_000_000
+
+// minTopCompaction keeps small searches from compacting their nodes often.
+const minTopCompaction = 1 << 16
+
+// topNode adds count packs of one size to the chain that leads back to the
+// empty packing. Sizes are added in ascending order and the packs of a size
+// merged into a single node, so every multiset has exactly one chain.
+type topNode struct {
+	parent int32
+	pack   int32
+	count  int32
+	tableEntry
+}
+
+// GetTopPacks returns up to k distinct packings ranked by the objective, best
+// first. It accepts the same options as GetPacks.
+//
+// Each of the top k packings ships less than N plus k times the largest pack,
+// since dropping packs from a bigger one yields k better packings. Every
+// amount below that keeps its k best chains, so memory grows with N*k and
+// orders that need more than maxTopNodes fail with ErrOrderTooLarge.
+func GetTopPacks(N int, packSizes []int, k int, opts ...Option) ([]*PackingResult, error) {
+	o := newOptions(opts)
+
+	sizes := normalizePackSizes(packSizes)
+	if len(sizes) == 0 {
+		return nil, ErrNoPackSizes
+	}
+	if err := checkCosts(o.costs); err != nil {
+		return nil, err
+	}
+	if k < 1 {
+		k = 1
+	}
+	if N < 0 {
+		N = 0
+	}
+	if overflows(N, k+1, sizes[len(sizes)-1]) {
+		return nil, ErrOrderTooLarge
+	}
+
+	bound := N + k*sizes[len(sizes)-1]
+	limits, capacity := o.availability.stockLimits(bound, sizes)
+
+	maxCheck := bound - 1
+	if len(limits) == len(sizes) {
+		if capacity < N {
+			return nil, newInsufficientStockError(N, sizes, o.availability, capacity)
+		}
+		maxCheck = min(maxCheck, capacity)
+	}
+	if o.objective == ObjectiveMinCostMaxOverage && o.maxOverage < maxCheck-N {
+		maxCheck = N + o.maxOverage
+	}
+	if (maxCheck+1)*k > maxTopNodes/2 {
+		return nil, ErrOrderTooLarge
+	}
+
+	cost := func(p int) int { return 0 }
+	if o.objective.usesCost() {
+		cost = func(p int) int { return o.costs[p] }
+	}
+
+	// lists holds up to k node indexes per amount, best first
+	lists := make([]int32, (maxCheck+1)*k)
+	lens := make([]int, maxCheck+1)
+	lists[0] = 0
+	lens[0] = 1
+
+	// Nodes pushed out of every list may still be the parents of listed
+	// ones, so the unreachable ones are dropped once there are compactAt.
+	// When more than three quarters are reachable, compactAt doubles up to
+	// maxTopNodes, past which the order is too large.
+	compactAt := min(max(2*len(lists), minTopCompaction), maxTopNodes)
+	// An extension adds up to k nodes past compactAt
+	nodes := make([]topNode, 1, compactAt+k)
+	nodes[0] = topNode{parent: -1}
+
+	extend := func(x, from, p, q int) bool {
+		for _, idx := range lists[from*k : from*k+lens[from]] {
+			prev := nodes[idx]
+			next := topNode{
+				parent: idx,
+				pack:   int32(p),
+				count:  int32(q),
+				tableEntry: tableEntry{
+					cost:  prev.cost + cost(p)*q,
+					packs: prev.packs + int32(q),
+				},
+			}
+			if prev.pack == int32(p) {
+				next.parent = prev.parent
+				next.count += prev.count
+			}
+			if !insertTopNode(&nodes, lists[x*k:(x+1)*k], &lens[x], next) {
+				// The list at from is sorted, so later entries cannot do better
+				break
+			}
+		}
+		if len(nodes) >= compactAt {
+			nodes = compactTopNodes(nodes, lists, lens, k)
+			if len(nodes) > compactAt/4*3 {
+				if compactAt == maxTopNodes {
+					return false
+				}
+				compactAt = min(2*compactAt, maxTopNodes)
+				nodes = append(make([]topNode, 0, compactAt+k), nodes...)
+			}
+		}
+		return true
+	}
+
+	for _, p := range sizes {
+		limit, limited := limits[p]
+		if !limited {
+			for x := p; x <= maxCheck; x++ {
+				if !extend(x, x-p, p, 1) {
+					return nil, ErrOrderTooLarge
+				}
+			}
+			continue
+		}
+
+		// Limited stock is added in chunks of 1, 2, 4, ... packs as in
+		// solveTable, going down so that each chunk is used at most once
+		for chunk := 1; limit > 0; chunk *= 2 {
+			q := min(chunk, limit)
+			limit -= q
+			for x := maxCheck; x >= q*p; x-- {
+				if !extend(x, x-q*p, p, q) {
+					return nil, ErrOrderTooLarge
+				}
+			}
+		}
+	}
+
+	type candidate struct {
+		amount int
+		idx    int32
+	}
+	var candidates []candidate
+	for x := N; x <= maxCheck; x++ {
+		for _, idx := range lists[x*k : x*k+lens[x]] {
+			candidates = append(candidates, candidate{amount: x, idx: idx})
+		}
+	}
+	if len(candidates) == 0 {
+		if o.objective == ObjectiveMinCostMaxOverage {
+			return nil, ErrOverageNotAllowed
+		}
+		return nil, ErrNoSolution
+	}
+
+	// Rank by cost, then by overage, then by pack count
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := nodes[candidates[i].idx], nodes[candidates[j].idx]
+		if a.cost != b.cost {
+			return a.cost < b.cost
+		}
+		if candidates[i].amount != candidates[j].amount {
+			return candidates[i].amount < candidates[j].amount
+		}
+		return a.packs < b.packs
+	})
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+
+	results := make([]*PackingResult, 0, len(candidates))
+	for _, c := range candidates {
+		packCount := make(map[int]int)
+		for idx := c.idx; nodes[idx].parent != -1; idx = nodes[idx].parent {
+			packCount[int(nodes[idx].pack)] += int(nodes[idx].count)
+		}
+		results = append(results, newPackingResult(N, packCount, o))
+	}
+
+	return results, nil
+}
+
+// insertTopNode adds node to the sorted list of length *n if it ranks within
+// the list's capacity and is not listed yet. It reports whether the node
+// ranks within the capacity.
+func insertTopNode(nodes *[]topNode, list []int32, n *int, node topNode) bool {
+	pos := *n
+	for pos > 0 && node.less((*nodes)[list[pos-1]].tableEntry) {
+		pos--
+	}
+	if pos == len(list) {
+		return false
+	}
+
+	// Different chunks of a limited size can add up to the same count, and
+	// the copy is then among the nodes that rank the same
+	for i := pos - 1; i >= 0 && !(*nodes)[list[i]].less(node.tableEntry); i-- {
+		if (*nodes)[list[i]] == node {
+			return true
+		}
+	}
+
+	*nodes = append(*nodes, node)
+	if *n < len(list) {
+		*n++
+	}
+	copy(list[pos+1:*n], list[pos:*n-1])
+	list[pos] = int32(len(*nodes) - 1)
+
+	return true
+}
+
+// compactTopNodes drops the nodes that no list leads to and renumbers the
+// rest in the same order, so parents still come before their children.
+func compactTopNodes(nodes []topNode, lists []int32, lens []int, k int) []topNode {
+	live := make([]bool, len(nodes))
+	live[0] = true
+	for x, n := range lens {
+		for _, idx := range lists[x*k : x*k+n] {
+			live[idx] = true
+		}
+	}
+	for i := len(nodes) - 1; i > 0; i-- {
+		if live[i] {
+			live[nodes[i].parent] = true
+		}
+	}
+
+	renumbered := make([]int32, len(nodes))
+	kept := 0
+	for i, node := range nodes {
+		if !live[i] {
+			continue
+		}
+		if node.parent != -1 {
+			node.parent = renumbered[node.parent]
+		}
+		renumbered[i] = int32(kept)
+		nodes[kept] = node
+		kept++
+	}
+
+	for x, n := range lens {
+		list := lists[x*k : x*k+n]
+		for j, idx := range list {
+			list[j] = renumbered[idx]
+		}
+	}
+
+	return nodes[:kept]
+}
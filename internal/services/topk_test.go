@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestGetTopPacks(t *testing.T) {
+	got, err := GetTopPacks(251, []int{250, 500}, 3)
+	if err != nil {
+		t.Fatalf("GetTopPacks() error = %v", err)
+	}
+
+	want := []map[int]int{{500: 1}, {250: 2}, {250: 1, 500: 1}}
+	if len(got) != len(want) {
+		t.Fatalf("GetTopPacks() returned %d solutions, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i].PackQuantity(), want[i]) {
+			t.Errorf("GetTopPacks()[%d] = %v, want %v", i, got[i].PackQuantity(), want[i])
+		}
+	}
+	if got[0].Overage != 249 || got[1].Overage != 249 || got[2].Overage != 499 {
+		t.Errorf("GetTopPacks() overages = %d, %d, %d, want 249, 249, 499", got[0].Overage, got[1].Overage, got[2].Overage)
+	}
+}
+
+func TestGetTopPacks_MatchesGetPacks(t *testing.T) {
+	for _, amount := range []int{1, 201, 251, 501, 12001} {
+		sizes := []int{250, 500, 1000, 2000, 5000}
+		top, err := GetTopPacks(amount, sizes, 1)
+		if err != nil {
+			t.Fatalf("GetTopPacks() error = %v", err)
+		}
+		best, err := GetPacks(amount, sizes)
+		if err != nil {
+			t.Fatalf("GetPacks() error = %v", err)
+		}
+		if top[0].ItemsShipped != best.ItemsShipped || top[0].TotalPacks != best.TotalPacks {
+			t.Errorf("GetTopPacks(%d) = %v, GetPacks() = %v", amount, top[0].PackQuantity(), best.PackQuantity())
+		}
+	}
+}
+
+func TestGetTopPacks_MaxOverageOfMaxInt(t *testing.T) {
+	got, err := GetTopPacks(1000, []int{250, 500, 1000}, 2,
+		WithObjective(ObjectiveMinCostMaxOverage), WithCosts(Costs{250: 100, 500: 150, 1000: 400}), WithMaxOverage(math.MaxInt))
+	if err != nil {
+		t.Fatalf("GetTopPacks() error = %v", err)
+	}
+	if !reflect.DeepEqual(got[0].PackQuantity(), map[int]int{500: 2}) {
+		t.Errorf("GetTopPacks()[0] = %v, want %v", got[0].PackQuantity(), map[int]int{500: 2})
+	}
+}
+
+func TestGetTopPacks_InsufficientStock(t *testing.T) {
+	_, err := GetTopPacks(501, []int{250, 500}, 3, WithAvailability(Availability{250: 1, 500: 0}))
+
+	var stockErr *InsufficientStockError
+	if !errors.As(err, &stockErr) {
+		t.Errorf("GetTopPacks() error = %v, want InsufficientStockError", err)
+	}
+}
+
+func TestGetTopPacks_LargeStock(t *testing.T) {
+	got, err := GetTopPacks(200000, []int{1, 7}, 5, WithAvailability(Availability{1: 150000}))
+	if err != nil {
+		t.Fatalf("GetTopPacks() error = %v", err)
+	}
+
+	// Every 7 traded for seven 1s adds six packs
+	if len(got) != 5 {
+		t.Fatalf("GetTopPacks() returned %d solutions, want 5", len(got))
+	}
+	for i, res := range got {
+		want := map[int]int{1: 3 + 7*i, 7: 28571 - i}
+		if !reflect.DeepEqual(res.PackQuantity(), want) {
+			t.Errorf("GetTopPacks()[%d] = %v, want %v", i, res.PackQuantity(), want)
+		}
+	}
+}
+
+func TestGetTopPacks_TooLarge(t *testing.T) {
+	_, err := GetTopPacks(990000, []int{1, 2, 3, 5, 7, 11, 13}, 10)
+	if !errors.Is(err, ErrOrderTooLarge) {
+		t.Errorf("GetTopPacks() error = %v, want ErrOrderTooLarge", err)
+	}
+}
+
+func TestGetTopPacks_MatchesBruteForce(t *testing.T) {
+	objectives := []Objective{ObjectiveMinOverage, ObjectiveMinCost}
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		sizes := rnd.Perm(20)[:1+rnd.Intn(3)]
+		costs := make(Costs)
+		availability := make(Availability)
+		for j := range sizes {
+			sizes[j]++
+			costs[sizes[j]] = rnd.Intn(20)
+			if rnd.Intn(3) == 0 {
+				availability[sizes[j]] = 1 + rnd.Intn(10)
+			}
+		}
+		N := 1 + rnd.Intn(60)
+		k := 1 + rnd.Intn(5)
+		objective := objectives[rnd.Intn(len(objectives))]
+
+		got, err := GetTopPacks(N, sizes, k, WithObjective(objective), WithCosts(costs), WithAvailability(availability))
+		want := bruteForceTopKeys(N, sizes, k, objective, costs, availability)
+		if len(want) == 0 {
+			if err == nil {
+				t.Fatalf("GetTopPacks(%d, %v, %d) = %v, want error", N, sizes, k, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("GetTopPacks(%d, %v, %d) error = %v", N, sizes, k, err)
+		}
+
+		keys := make([][3]int, 0, len(got))
+		seen := make(map[string]bool)
+		for _, res := range got {
+			keys = append(keys, [3]int{res.TotalCost, res.Overage, res.TotalPacks})
+			for size, quantity := range res.PackQuantity() {
+				if n, ok := availability[size]; ok && quantity > n {
+					t.Fatalf("GetTopPacks(%d, %v, %d) uses more than in stock: %v", N, sizes, k, res.PackQuantity())
+				}
+			}
+			id := fmt.Sprint(res.Packs)
+			if seen[id] {
+				t.Fatalf("GetTopPacks(%d, %v, %d) returned %v twice", N, sizes, k, res.PackQuantity())
+			}
+			seen[id] = true
+		}
+		if objective == ObjectiveMinOverage {
+			for j := range keys {
+				keys[j][0] = 0
+			}
+		}
+		if !reflect.DeepEqual(keys, want) {
+			t.Fatalf("GetTopPacks(%d, %v, %d, %s) keys = %v, want %v", N, sizes, k, objective, keys, want)
+		}
+	}
+}
+
+// bruteForceTopKeys returns the (cost, overage, packs) keys of the k best
+// packings, with cost zeroed for ObjectiveMinOverage.
+func bruteForceTopKeys(N int, sizes []int, k int, objective Objective, costs Costs, availability Availability) [][3]int {
+	var keys [][3]int
+	largest := sizes[0]
+	for _, p := range sizes {
+		largest = max(largest, p)
+	}
+
+	var walk func(i, sum, c, n int)
+	walk = func(i, sum, c, n int) {
+		if i == len(sizes) {
+			if sum >= N {
+				if objective == ObjectiveMinOverage {
+					c = 0
+				}
+				keys = append(keys, [3]int{c, sum - N, n})
+			}
+			return
+		}
+		for q := 0; sum+q*sizes[i] < N+k*largest; q++ {
+			if a, ok := availability[sizes[i]]; ok && q > a {
+				break
+			}
+			walk(i+1, sum+q*sizes[i], c+q*costs[sizes[i]], n+q)
+		}
+	}
+	walk(0, 0, 0, 0)
+
+	sort.Slice(keys, func(i, j int) bool {
+		for f := 0; f < 3; f++ {
+			if keys[i][f] != keys[j][f] {
+				return keys[i][f] < keys[j][f]
+			}
+		}
+		return false
+	})
+	if len(keys) > k {
+		keys = keys[:k]
+	}
+
+	return keys
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MaxPackCost is the largest cost of a single pack. The solvers never build
+// packings of more than a few billion packs, so their costs fit in an int.
+const MaxPackCost = 1_000_000_000
+
+var (
+	ErrNoPackSizes        = errors.New("at least one pack size is required")
+	ErrInvalidPackSize    = errors.New("pack sizes must be greater than zero")
+	ErrDuplicatePackSize  = errors.New("pack sizes must be unique")
+	ErrPackSizeTooLarge   = errors.New("pack size exceeds the maximum allowed")
+	ErrInvalidItemsAmount = errors.New("items must be greater than zero")
+	ErrItemsTooLarge      = errors.New("items exceeds the maximum allowed")
+	ErrInvalidStock       = errors.New("stock must not be negative")
+	ErrUnknownStockSize   = errors.New("stock given for a pack size that is not in the order")
+	ErrUnknownObjective   = errors.New("unknown objective")
+	ErrMissingCost        = errors.New("cost is required for every pack size")
+	ErrInvalidCost        = errors.New("cost must not be negative")
+	ErrCostTooLarge       = errors.New("cost exceeds the maximum allowed")
+	ErrUnknownCostSize    = errors.New("cost given for a pack size that is not in the order")
+	ErrMissingMaxOverage  = errors.New("maxOverage is required for this objective")
+	ErrInvalidMaxOverage  = errors.New("maxOverage must not be negative")
+)
+
+// ValidateOrder checks that an order can be packed. A maxPackSize or
+// maxItems of zero disables that limit.
+func ValidateOrder(items int, packSizes []int, maxPackSize, maxItems int) error {
+	if items <= 0 {
+		return ErrInvalidItemsAmount
+	}
+	if maxItems > 0 && items > maxItems {
+		return fmt.Errorf("%w of %d: %d", ErrItemsTooLarge, maxItems, items)
+	}
+
+	if len(packSizes) == 0 {
+		return ErrNoPackSizes
+	}
+
+	seen := make(map[int]bool, len(packSizes))
+	for _, p := range packSizes {
+		if p <= 0 {
+			return fmt.Errorf("%w: %d", ErrInvalidPackSize, p)
+		}
+		if maxPackSize > 0 && p > maxPackSize {
+			return fmt.Errorf("%w of %d: %d", ErrPackSizeTooLarge, maxPackSize, p)
+		}
+		if seen[p] {
+			return fmt.Errorf("%w: %d", ErrDuplicatePackSize, p)
+		}
+		seen[p] = true
+	}
+
+	return nil
+}
+
+// ValidateAvailability checks that stock is only given for the order's pack
+// sizes and is never negative.
+func ValidateAvailability(packSizes []int, availability Availability) error {
+	known := make(map[int]bool, len(packSizes))
+	for _, p := range packSizes {
+		known[p] = true
+	}
+
+	sizes := make([]int, 0, len(availability))
+	for size := range availability {
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+
+	for _, size := range sizes {
+		if !known[size] {
+			return fmt.Errorf("%w: %d", ErrUnknownStockSize, size)
+		}
+		if availability[size] < 0 {
+			return fmt.Errorf("%w: %d", ErrInvalidStock, size)
+		}
+	}
+
+	return nil
+}
+
+// ValidateObjective checks that the costs and overage limit needed by the
+// objective are given. maxOverage is nil when no limit was requested.
+func ValidateObjective(objective Objective, packSizes []int, costs Costs, maxOverage *int) error {
+	known := make(map[int]bool, len(packSizes))
+	for _, p := range packSizes {
+		known[p] = true
+	}
+
+	sizes := make([]int, 0, len(costs))
+	for size := range costs {
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+
+	for _, size := range sizes {
+		if !known[size] {
+			return fmt.Errorf("%w: %d", ErrUnknownCostSize, size)
+		}
+		if costs[size] < 0 {
+			return fmt.Errorf("%w: %d", ErrInvalidCost, size)
+		}
+	}
+	if err := checkCosts(costs); err != nil {
+		return err
+	}
+
+	if objective.usesCost() {
+		for _, p := range packSizes {
+			if _, ok := costs[p]; !ok {
+				return fmt.Errorf("%w: %d", ErrMissingCost, p)
+			}
+		}
+	}
+
+	if objective == ObjectiveMinCostMaxOverage && maxOverage == nil {
+		return ErrMissingMaxOverage
+	}
+	if maxOverage != nil && *maxOverage < 0 {
+		return ErrInvalidMaxOverage
+	}
+
+	return nil
+}
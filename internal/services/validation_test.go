@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestValidateOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		items       int
+		packSizes   []int
+		maxPackSize int
+		maxItems    int
+		wantErr     error
+		wantMsg     string
+	}{
+		{"valid", 251, []int{500, 250, 1000}, 5000, 1000000, nil, ""},
+		{"no limit", 251, []int{50000}, 0, 1000000, nil, ""},
+		{"zero items", 0, []int{250}, 5000, 1000000, ErrInvalidItemsAmount, "items must be greater than zero"},
+		{"negative items", -1, []int{250}, 5000, 1000000, ErrInvalidItemsAmount, "items must be greater than zero"},
+		{"no pack sizes", 10, nil, 5000, 1000000, ErrNoPackSizes, "at least one pack size is required"},
+		{"zero pack size", 10, []int{250, 0}, 5000, 1000000, ErrInvalidPackSize, "pack sizes must be greater than zero: 0"},
+		{"negative pack size", 10, []int{-5}, 5000, 1000000, ErrInvalidPackSize, "pack sizes must be greater than zero: -5"},
+		{"duplicate pack size", 10, []int{250, 500, 250}, 5000, 1000000, ErrDuplicatePackSize, "pack sizes must be unique: 250"},
+		{"items at limit", 1000000, []int{250}, 5000, 1000000, nil, ""},
+		{"items above limit", 1000001, []int{250}, 5000, 1000000, ErrItemsTooLarge, "items exceeds the maximum allowed of 1000000: 1000001"},
+		{"no items limit", math.MaxInt, []int{250}, 5000, 0, nil, ""},
+		{"pack size above limit", 10, []int{250, 6000}, 5000, 1000000, ErrPackSizeTooLarge, "pack size exceeds the maximum allowed of 5000: 6000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOrder(tt.items, tt.packSizes, tt.maxPackSize, tt.maxItems)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil && err.Error() != tt.wantMsg {
+				t.Errorf("ValidateOrder() error = %q, want %q", err.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestValidateAvailability(t *testing.T) {
+	tests := []struct {
+		name         string
+		packSizes    []int
+		availability Availability
+		wantErr      error
+		wantMsg      string
+	}{
+		{"no stock", []int{250, 500}, nil, nil, ""},
+		{"valid", []int{250, 500}, Availability{250: 0, 500: 3}, nil, ""},
+		{"negative stock", []int{250, 500}, Availability{500: -1}, ErrInvalidStock, "stock must not be negative: 500"},
+		{"unknown size", []int{250, 500}, Availability{300: 1}, ErrUnknownStockSize, "stock given for a pack size that is not in the order: 300"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAvailability(tt.packSizes, tt.availability)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateAvailability() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil && err.Error() != tt.wantMsg {
+				t.Errorf("ValidateAvailability() error = %q, want %q", err.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestParseObjective(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Objective
+		wantErr error
+	}{
+		{"", ObjectiveMinOverage, nil},
+		{"min_overage", ObjectiveMinOverage, nil},
+		{"min_cost", ObjectiveMinCost, nil},
+		{"min_cost_max_overage", ObjectiveMinCostMaxOverage, nil},
+		{"cheapest", "", ErrUnknownObjective},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseObjective(tt.name)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseObjective() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseObjective() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateObjective(t *testing.T) {
+	zero, negative := 0, -1
+
+	tests := []struct {
+		name       string
+		objective  Objective
+		packSizes  []int
+		costs      Costs
+		maxOverage *int
+		wantErr    error
+		wantMsg    string
+	}{
+		{"min overage without costs", ObjectiveMinOverage, []int{250, 500}, nil, nil, nil, ""},
+		{"min cost", ObjectiveMinCost, []int{250, 500}, Costs{250: 10, 500: 15}, nil, nil, ""},
+		{"min cost within overage", ObjectiveMinCostMaxOverage, []int{250}, Costs{250: 10}, &zero, nil, ""},
+		{"missing cost", ObjectiveMinCost, []int{250, 500}, Costs{250: 10}, nil, ErrMissingCost, "cost is required for every pack size: 500"},
+		{"negative cost", ObjectiveMinCost, []int{250}, Costs{250: -1}, nil, ErrInvalidCost, "cost must not be negative: 250"},
+		{"cost too large", ObjectiveMinCost, []int{250}, Costs{250: MaxPackCost + 1}, nil, ErrCostTooLarge, "cost exceeds the maximum allowed of 1000000000: 250"},
+		{"unknown cost size", ObjectiveMinOverage, []int{250}, Costs{300: 1}, nil, ErrUnknownCostSize, "cost given for a pack size that is not in the order: 300"},
+		{"missing max overage", ObjectiveMinCostMaxOverage, []int{250}, Costs{250: 10}, nil, ErrMissingMaxOverage, "maxOverage is required for this objective"},
+		{"negative max overage", ObjectiveMinCostMaxOverage, []int{250}, Costs{250: 10}, &negative, ErrInvalidMaxOverage, "maxOverage must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateObjective(tt.objective, tt.packSizes, tt.costs, tt.maxOverage)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateObjective() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil && err.Error() != tt.wantMsg {
+				t.Errorf("ValidateObjective() error = %q, want %q", err.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"packs-api/internal/metrics"
+	"packs-api/internal/resources"
+)
+
+// instrumentedStore records the latency and failures of every operation of
+// the wrapped store.
+type instrumentedStore struct {
+	next NoSQLStore
+}
+
+// NewInstrumentedStore wraps next so that its operations are recorded in
+// metrics.StoreOperationDuration and metrics.StoreErrors. ErrNotFound and
+// ErrDuplicate are answers rather than failures, so they are not counted as
+// errors.
+func NewInstrumentedStore(next NoSQLStore) NoSQLStore {
+	return &instrumentedStore{next: next}
+}
+
+func observe(operation string, start time.Time, failed bool) {
+	metrics.StoreOperationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if failed {
+		metrics.StoreErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+func isFailure(err error) bool {
+	return err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrDuplicate)
+}
+
+func (s *instrumentedStore) CheckHealth(ctx context.Context) bool {
+	start := time.Now()
+	ok := s.next.CheckHealth(ctx)
+	observe("check_health", start, !ok)
+	return ok
+}
+
+func (s *instrumentedStore) Close() error {
+	start := time.Now()
+	err := s.next.Close()
+	observe("close", start, isFailure(err))
+	return err
+}
+
+func (s *instrumentedStore) CreateOrder(ctx context.Context, order *resources.Order) error {
+	start := time.Now()
+	err := s.next.CreateOrder(ctx, order)
+	observe("create_order", start, isFailure(err))
+	return err
+}
+
+func (s *instrumentedStore) GetAllOrders(ctx context.Context, query OrderQuery) ([]*resources.Order, error) {
+	start := time.Now()
+	orders, err := s.next.GetAllOrders(ctx, query)
+	observe("get_all_orders", start, isFailure(err))
+	return orders, err
+}
+
+func (s *instrumentedStore) GetOrder(ctx context.Context, id primitive.ObjectID) (*resources.Order, error) {
+	start := time.Now()
+	order, err := s.next.GetOrder(ctx, id)
+	observe("get_order", start, isFailure(err))
+	return order, err
+}
+
+func (s *instrumentedStore) UpdateOrder(ctx context.Context, order *resources.Order) error {
+	start := time.Now()
+	err := s.next.UpdateOrder(ctx, order)
+	observe("update_order", start, isFailure(err))
+	return err
+}
+
+func (s *instrumentedStore) DeleteOrder(ctx context.Context, id primitive.ObjectID) error {
+	start := time.Now()
+	err := s.next.DeleteOrder(ctx, id)
+	observe("delete_order", start, isFailure(err))
+	return err
+}
+
+func (s *instrumentedStore) GetAPIKey(ctx context.Context, keyHash string) (*resources.APIKey, error) {
+	start := time.Now()
+	apiKey, err := s.next.GetAPIKey(ctx, keyHash)
+	observe("get_api_key", start, isFailure(err))
+	return apiKey, err
+}
+
+func (s *instrumentedStore) CreateIdempotencyRecord(ctx context.Context, record *resources.IdempotencyRecord) error {
+	start := time.Now()
+	err := s.next.CreateIdempotencyRecord(ctx, record)
+	observe("create_idempotency_record", start, isFailure(err))
+	return err
+}
+
+func (s *instrumentedStore) GetIdempotencyRecord(ctx context.Context, key string) (*resources.IdempotencyRecord, error) {
+	start := time.Now()
+	record, err := s.next.GetIdempotencyRecord(ctx, key)
+	observe("get_idempotency_record", start, isFailure(err))
+	return record, err
+}
+
+func (s *instrumentedStore) UpdateIdempotencyRecord(ctx context.Context, record *resources.IdempotencyRecord) error {
+	start := time.Now()
+	err := s.next.UpdateIdempotencyRecord(ctx, record)
+	observe("update_idempotency_record", start, isFailure(err))
+	return err
+}
+
+func (s *instrumentedStore) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	start := time.Now()
+	err := s.next.DeleteIdempotencyRecord(ctx, key)
+	observe("delete_idempotency_record", start, isFailure(err))
+	return err
+}
@@ -0,0 +1,55 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"packs-api/internal/metrics"
+	"packs-api/internal/store"
+	"packs-api/mocks"
+)
+
+func TestInstrumentedStore_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCount float64
+	}{
+		{"success", nil, 0},
+		{"not found", store.ErrNotFound, 0},
+		{"failure", errors.New("connection refused"), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mongoDB := mocks.NewMockNoSQLStore(ctrl)
+			id := primitive.NewObjectID()
+			mongoDB.EXPECT().DeleteOrder(gomock.Any(), id).Return(tt.err)
+
+			errorsBefore := testutil.ToFloat64(metrics.StoreErrors.WithLabelValues("delete_order"))
+			observedBefore := sampleCount(t, metrics.StoreOperationDuration.WithLabelValues("delete_order"))
+
+			err := store.NewInstrumentedStore(mongoDB).DeleteOrder(context.Background(), id)
+
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, errorsBefore+tt.wantCount, testutil.ToFloat64(metrics.StoreErrors.WithLabelValues("delete_order")))
+			assert.Equal(t, observedBefore+1, sampleCount(t, metrics.StoreOperationDuration.WithLabelValues("delete_order")))
+		})
+	}
+}
+
+// sampleCount returns the number of observations of a histogram.
+func sampleCount(t *testing.T, o prometheus.Observer) uint64 {
+	m := &dto.Metric{}
+	assert.Nil(t, o.(prometheus.Metric).Write(m))
+	return m.GetHistogram().GetSampleCount()
+}
@@ -6,8 +6,10 @@ import (
 	"crypto/x509"
 	"errors"
 	"os"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -16,7 +18,11 @@ import (
 	"path/filepath"
 )
 
-const ordersCollection = "orders"
+const (
+	ordersCollection      = "orders"
+	apiKeysCollection     = "api_keys"
+	idempotencyCollection = "idempotency_keys"
+)
 
 type NoSQLStore interface {
 	// CheckHealth returns the status of the store.
@@ -26,9 +32,39 @@ type NoSQLStore interface {
 	Close() error
 
 	CreateOrder(ctx context.Context, order *resources.Order) error
-	GetAllOrders(ctx context.Context) ([]*resources.Order, error)
+	// GetAllOrders returns up to query.Limit orders matching query, in its
+	// sort order.
+	GetAllOrders(ctx context.Context, query OrderQuery) ([]*resources.Order, error)
+
+	// GetOrder returns ErrNotFound if no order has the given ID.
+	GetOrder(ctx context.Context, id primitive.ObjectID) (*resources.Order, error)
+	// UpdateOrder replaces the order with the same ID, or returns ErrNotFound.
+	UpdateOrder(ctx context.Context, order *resources.Order) error
+	// DeleteOrder returns ErrNotFound if no order has the given ID.
+	DeleteOrder(ctx context.Context, id primitive.ObjectID) error
+
+	// GetAPIKey returns the API key stored under keyHash, or ErrNotFound.
+	GetAPIKey(ctx context.Context, keyHash string) (*resources.APIKey, error)
+
+	// CreateIdempotencyRecord returns ErrDuplicate if the key is taken.
+	CreateIdempotencyRecord(ctx context.Context, record *resources.IdempotencyRecord) error
+	// GetIdempotencyRecord returns ErrNotFound if the key is not taken.
+	GetIdempotencyRecord(ctx context.Context, key string) (*resources.IdempotencyRecord, error)
+	// UpdateIdempotencyRecord replaces the record with the same key, or
+	// returns ErrNotFound.
+	UpdateIdempotencyRecord(ctx context.Context, record *resources.IdempotencyRecord) error
+	// DeleteIdempotencyRecord frees the key. Deleting a free key is not an
+	// error.
+	DeleteIdempotencyRecord(ctx context.Context, key string) error
 }
 
+var (
+	// ErrNotFound is returned when a requested document does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicate is returned when a document with the same key exists.
+	ErrDuplicate = errors.New("duplicate key")
+)
+
 // MongoDB represents a MongoDB client.
 type MongoDB struct {
 	Client *mongo.Client
@@ -36,10 +72,31 @@ type MongoDB struct {
 	DB *mongo.Database
 }
 
+// PoolOptions size the MongoDB connection pool. Zero values keep what the
+// URI or the driver defaults set.
+type PoolOptions struct {
+	MaxPoolSize     uint64
+	MinPoolSize     uint64
+	MaxConnIdleTime time.Duration
+	ConnectTimeout  time.Duration
+}
+
 // NewMongoDB returns new a MongoDB client.
-func NewMongoDB(uri, dbName, certPath string) (*MongoDB, error) {
+func NewMongoDB(uri, dbName, certPath string, pool PoolOptions) (*MongoDB, error) {
 
 	clientOptions := options.Client().ApplyURI(uri)
+	if pool.MaxPoolSize > 0 {
+		clientOptions.SetMaxPoolSize(pool.MaxPoolSize)
+	}
+	if pool.MinPoolSize > 0 {
+		clientOptions.SetMinPoolSize(pool.MinPoolSize)
+	}
+	if pool.MaxConnIdleTime > 0 {
+		clientOptions.SetMaxConnIdleTime(pool.MaxConnIdleTime)
+	}
+	if pool.ConnectTimeout > 0 {
+		clientOptions.SetConnectTimeout(pool.ConnectTimeout)
+	}
 
 	if certPath != "" {
 		c, err := getCustomTLSConfig(certPath)
@@ -63,6 +120,18 @@ func NewMongoDB(uri, dbName, certPath string) (*MongoDB, error) {
 	return mongoDB, nil
 }
 
+// EnsureIndexes creates the indexes the store relies on if they are missing:
+// the TTL index that has MongoDB delete idempotency records once they expire.
+func (mongoDB *MongoDB) EnsureIndexes(ctx context.Context) error {
+	coll := mongoDB.DB.Collection(idempotencyCollection)
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+
+	return err
+}
+
 func getCustomTLSConfig(caFile string) (*tls.Config, error) {
 	tlsConfig := new(tls.Config)
 	certs, err := os.ReadFile(filepath.Clean(caFile))
@@ -100,12 +169,17 @@ func (mongoDB *MongoDB) CreateOrder(ctx context.Context, order *resources.Order)
 	return err
 }
 
-func (mongoDB *MongoDB) GetAllOrders(ctx context.Context) ([]*resources.Order, error) {
-	matchStage := bson.D{{Key: "$match", Value: bson.D{{}}}}
+func (mongoDB *MongoDB) GetAllOrders(ctx context.Context, query OrderQuery) ([]*resources.Order, error) {
+	matchStage := bson.D{{Key: "$match", Value: query.filter()}}
+	sortStage := bson.D{{Key: "$sort", Value: query.sort()}}
+	pipeline := mongo.Pipeline{matchStage, sortStage}
+	if query.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: query.Limit}})
+	}
 	orders := make([]*resources.Order, 0)
 
 	coll := mongoDB.DB.Collection(ordersCollection)
-	cur, err := coll.Aggregate(ctx, mongo.Pipeline{matchStage})
+	cur, err := coll.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
@@ -117,3 +191,107 @@ func (mongoDB *MongoDB) GetAllOrders(ctx context.Context) ([]*resources.Order, e
 
 	return orders, nil
 }
+
+func (mongoDB *MongoDB) GetOrder(ctx context.Context, id primitive.ObjectID) (*resources.Order, error) {
+	coll := mongoDB.DB.Collection(ordersCollection)
+
+	var order resources.Order
+	err := coll.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&order)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+func (mongoDB *MongoDB) UpdateOrder(ctx context.Context, order *resources.Order) error {
+	coll := mongoDB.DB.Collection(ordersCollection)
+	res, err := coll.ReplaceOne(ctx, bson.D{{Key: "_id", Value: order.ID}}, order)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (mongoDB *MongoDB) DeleteOrder(ctx context.Context, id primitive.ObjectID) error {
+	coll := mongoDB.DB.Collection(ordersCollection)
+	res, err := coll.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return err
+	}
+
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (mongoDB *MongoDB) GetAPIKey(ctx context.Context, keyHash string) (*resources.APIKey, error) {
+	coll := mongoDB.DB.Collection(apiKeysCollection)
+
+	var apiKey resources.APIKey
+	err := coll.FindOne(ctx, bson.D{{Key: "key_hash", Value: keyHash}}).Decode(&apiKey)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+func (mongoDB *MongoDB) CreateIdempotencyRecord(ctx context.Context, record *resources.IdempotencyRecord) error {
+	coll := mongoDB.DB.Collection(idempotencyCollection)
+	_, err := coll.InsertOne(ctx, record)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+
+	return err
+}
+
+func (mongoDB *MongoDB) GetIdempotencyRecord(ctx context.Context, key string) (*resources.IdempotencyRecord, error) {
+	coll := mongoDB.DB.Collection(idempotencyCollection)
+
+	var record resources.IdempotencyRecord
+	err := coll.FindOne(ctx, bson.D{{Key: "_id", Value: key}}).Decode(&record)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (mongoDB *MongoDB) UpdateIdempotencyRecord(ctx context.Context, record *resources.IdempotencyRecord) error {
+	coll := mongoDB.DB.Collection(idempotencyCollection)
+	res, err := coll.ReplaceOne(ctx, bson.D{{Key: "_id", Value: record.Key}}, record)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (mongoDB *MongoDB) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	coll := mongoDB.DB.Collection(idempotencyCollection)
+	_, err := coll.DeleteOne(ctx, bson.D{{Key: "_id", Value: key}})
+
+	return err
+}
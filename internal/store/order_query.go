@@ -0,0 +1,108 @@
+package store
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrderSortField names an order field that listings can be sorted by.
+type OrderSortField string
+
+const (
+	SortByCreatedAt OrderSortField = "created_at"
+	SortByItems     OrderSortField = "items"
+)
+
+// OrderQuery filters, sorts and pages order listings. Nil filters are not
+// applied and a zero Limit returns every match.
+type OrderQuery struct {
+	Limit int
+	// After continues a listing after the given order, in the same sort order.
+	After *OrderCursor
+
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MinItems    *int
+	MaxItems    *int
+	// PackSize matches orders whose pack sizes include it.
+	PackSize *int
+
+	SortBy   OrderSortField
+	SortDesc bool
+}
+
+// OrderCursor is the position of an order in a listing. Orders with equal
+// sort values are ordered by ID.
+type OrderCursor struct {
+	ID        primitive.ObjectID `json:"id"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Items     int                `json:"items"`
+}
+
+func (q OrderQuery) sortBy() OrderSortField {
+	if q.SortBy == "" {
+		return SortByCreatedAt
+	}
+	return q.SortBy
+}
+
+func (q OrderQuery) filter() bson.D {
+	filter := bson.D{}
+
+	createdAt := bson.D{}
+	if q.CreatedFrom != nil {
+		createdAt = append(createdAt, bson.E{Key: "$gte", Value: *q.CreatedFrom})
+	}
+	if q.CreatedTo != nil {
+		createdAt = append(createdAt, bson.E{Key: "$lt", Value: *q.CreatedTo})
+	}
+	if len(createdAt) > 0 {
+		filter = append(filter, bson.E{Key: "created_at", Value: createdAt})
+	}
+
+	items := bson.D{}
+	if q.MinItems != nil {
+		items = append(items, bson.E{Key: "$gte", Value: *q.MinItems})
+	}
+	if q.MaxItems != nil {
+		items = append(items, bson.E{Key: "$lte", Value: *q.MaxItems})
+	}
+	if len(items) > 0 {
+		filter = append(filter, bson.E{Key: "items", Value: items})
+	}
+
+	if q.PackSize != nil {
+		filter = append(filter, bson.E{Key: "pack_sizes", Value: *q.PackSize})
+	}
+
+	if q.After != nil {
+		op := "$gt"
+		if q.SortDesc {
+			op = "$lt"
+		}
+
+		field := string(q.sortBy())
+		var value interface{} = q.After.CreatedAt
+		if q.sortBy() == SortByItems {
+			value = q.After.Items
+		}
+
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: field, Value: bson.D{{Key: op, Value: value}}}},
+			bson.D{{Key: field, Value: value}, {Key: "_id", Value: bson.D{{Key: op, Value: q.After.ID}}}},
+		}})
+	}
+
+	return filter
+}
+
+func (q OrderQuery) sort() bson.D {
+	direction := 1
+	if q.SortDesc {
+		direction = -1
+	}
+
+	return bson.D{{Key: string(q.sortBy()), Value: direction}, {Key: "_id", Value: direction}}
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOrderQuery_Filter(t *testing.T) {
+	id := primitive.NewObjectID()
+	createdAt := time.Date(2023, 11, 04, 20, 34, 58, 0, time.UTC)
+	from := time.Date(2023, 11, 01, 0, 0, 0, 0, time.UTC)
+	minItems, packSize := 5, 250
+
+	tests := []struct {
+		name  string
+		query OrderQuery
+		want  bson.D
+	}{
+		{"empty", OrderQuery{}, bson.D{}},
+		{
+			"filters",
+			OrderQuery{CreatedFrom: &from, MinItems: &minItems, PackSize: &packSize},
+			bson.D{
+				{Key: "created_at", Value: bson.D{{Key: "$gte", Value: from}}},
+				{Key: "items", Value: bson.D{{Key: "$gte", Value: 5}}},
+				{Key: "pack_sizes", Value: 250},
+			},
+		},
+		{
+			"after by created_at",
+			OrderQuery{After: &OrderCursor{ID: id, CreatedAt: createdAt, Items: 10}},
+			bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "created_at", Value: bson.D{{Key: "$gt", Value: createdAt}}}},
+				bson.D{{Key: "created_at", Value: createdAt}, {Key: "_id", Value: bson.D{{Key: "$gt", Value: id}}}},
+			}}},
+		},
+		{
+			"after by items descending",
+			OrderQuery{After: &OrderCursor{ID: id, CreatedAt: createdAt, Items: 10}, SortBy: SortByItems, SortDesc: true},
+			bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "items", Value: bson.D{{Key: "$lt", Value: 10}}}},
+				bson.D{{Key: "items", Value: 10}, {Key: "_id", Value: bson.D{{Key: "$lt", Value: id}}}},
+			}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.query.filter())
+		})
+	}
+}
+
+func TestOrderQuery_Sort(t *testing.T) {
+	assert.Equal(t, bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}, OrderQuery{}.sort())
+	assert.Equal(t, bson.D{{Key: "items", Value: -1}, {Key: "_id", Value: -1}}, OrderQuery{SortBy: SortByItems, SortDesc: true}.sort())
+}
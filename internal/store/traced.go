@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"packs-api/internal/resources"
+	"packs-api/internal/tracing"
+)
+
+// tracedStore records a span for every operation of the wrapped store.
+type tracedStore struct {
+	next   NoSQLStore
+	tracer tracing.Tracer
+}
+
+// NewTracedStore wraps next so that its operations are recorded as spans of
+// tracer. As in NewInstrumentedStore, ErrNotFound and ErrDuplicate do not
+// fail the span.
+func NewTracedStore(next NoSQLStore, tracer tracing.Tracer) NoSQLStore {
+	return &tracedStore{next: next, tracer: tracer}
+}
+
+func (s *tracedStore) start(ctx context.Context, name string) (context.Context, tracing.Span) {
+	return s.tracer.Start(ctx, "store."+name, tracing.SpanTypeDB)
+}
+
+func endSpan(span tracing.Span, err error) {
+	if isFailure(err) {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+func (s *tracedStore) CheckHealth(ctx context.Context) bool {
+	ctx, span := s.start(ctx, "CheckHealth")
+	ok := s.next.CheckHealth(ctx)
+	if !ok {
+		span.RecordError(errors.New("store is not healthy"))
+	}
+	span.End()
+	return ok
+}
+
+// Close is not traced, as it runs after the last request.
+func (s *tracedStore) Close() error {
+	return s.next.Close()
+}
+
+func (s *tracedStore) CreateOrder(ctx context.Context, order *resources.Order) error {
+	ctx, span := s.start(ctx, "CreateOrder")
+	err := s.next.CreateOrder(ctx, order)
+	endSpan(span, err)
+	return err
+}
+
+func (s *tracedStore) GetAllOrders(ctx context.Context, query OrderQuery) ([]*resources.Order, error) {
+	ctx, span := s.start(ctx, "GetAllOrders")
+	orders, err := s.next.GetAllOrders(ctx, query)
+	endSpan(span, err)
+	return orders, err
+}
+
+func (s *tracedStore) GetOrder(ctx context.Context, id primitive.ObjectID) (*resources.Order, error) {
+	ctx, span := s.start(ctx, "GetOrder")
+	order, err := s.next.GetOrder(ctx, id)
+	endSpan(span, err)
+	return order, err
+}
+
+func (s *tracedStore) UpdateOrder(ctx context.Context, order *resources.Order) error {
+	ctx, span := s.start(ctx, "UpdateOrder")
+	err := s.next.UpdateOrder(ctx, order)
+	endSpan(span, err)
+	return err
+}
+
+func (s *tracedStore) DeleteOrder(ctx context.Context, id primitive.ObjectID) error {
+	ctx, span := s.start(ctx, "DeleteOrder")
+	err := s.next.DeleteOrder(ctx, id)
+	endSpan(span, err)
+	return err
+}
+
+func (s *tracedStore) GetAPIKey(ctx context.Context, keyHash string) (*resources.APIKey, error) {
+	ctx, span := s.start(ctx, "GetAPIKey")
+	apiKey, err := s.next.GetAPIKey(ctx, keyHash)
+	endSpan(span, err)
+	return apiKey, err
+}
+
+func (s *tracedStore) CreateIdempotencyRecord(ctx context.Context, record *resources.IdempotencyRecord) error {
+	ctx, span := s.start(ctx, "CreateIdempotencyRecord")
+	err := s.next.CreateIdempotencyRecord(ctx, record)
+	endSpan(span, err)
+	return err
+}
+
+func (s *tracedStore) GetIdempotencyRecord(ctx context.Context, key string) (*resources.IdempotencyRecord, error) {
+	ctx, span := s.start(ctx, "GetIdempotencyRecord")
+	record, err := s.next.GetIdempotencyRecord(ctx, key)
+	endSpan(span, err)
+	return record, err
+}
+
+func (s *tracedStore) UpdateIdempotencyRecord(ctx context.Context, record *resources.IdempotencyRecord) error {
+	ctx, span := s.start(ctx, "UpdateIdempotencyRecord")
+	err := s.next.UpdateIdempotencyRecord(ctx, record)
+	endSpan(span, err)
+	return err
+}
+
+func (s *tracedStore) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	ctx, span := s.start(ctx, "DeleteIdempotencyRecord")
+	err := s.next.DeleteIdempotencyRecord(ctx, key)
+	endSpan(span, err)
+	return err
+}
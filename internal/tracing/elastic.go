@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+	"go.elastic.co/apm/module/apmgorilla"
+	"go.elastic.co/apm/module/apmlogrus"
+)
+
+// elasticTracer records requests as Elastic APM transactions and other spans
+// as their spans, with apm.DefaultTracer.
+type elasticTracer struct{}
+
+func (elasticTracer) Instrument(r *mux.Router) {
+	apmgorilla.Instrument(r)
+}
+
+func (elasticTracer) Start(ctx context.Context, name, spanType string) (context.Context, Span) {
+	span, ctx := apm.StartSpan(ctx, name, spanType)
+	return ctx, &elasticSpan{ctx: ctx, span: span}
+}
+
+// RecordPanic sends v as an APM error, with the stack from the caller, which
+// must be the deferred function that recovered it.
+func (elasticTracer) RecordPanic(ctx context.Context, v interface{}) {
+	e := apm.DefaultTracer.Recovered(v)
+	if tx := apm.TransactionFromContext(ctx); tx != nil {
+		e.SetTransaction(tx)
+	}
+	e.SetStacktrace(1)
+	e.Send()
+}
+
+func (elasticTracer) LogFields(ctx context.Context) logrus.Fields {
+	return apmlogrus.TraceContext(ctx)
+}
+
+func (elasticTracer) Shutdown(ctx context.Context) error {
+	apm.DefaultTracer.Flush(ctx.Done())
+	return nil
+}
+
+type elasticSpan struct {
+	ctx  context.Context
+	span *apm.Span
+}
+
+func (s *elasticSpan) RecordError(err error) {
+	s.span.Outcome = "failure"
+	if e := apm.CaptureError(s.ctx, err); e != nil {
+		e.Send()
+	}
+}
+
+func (s *elasticSpan) End() {
+	s.span.End()
+}
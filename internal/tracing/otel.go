@@ -0,0 +1,169 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelTracer records spans with the OpenTelemetry SDK. Incoming requests
+// continue the trace of their traceparent header.
+type otelTracer struct {
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	// out is closed on Shutdown, nil when the exporter owns its output.
+	out io.Closer
+}
+
+func newOTelTracer(exporter sdktrace.SpanExporter, service string, out io.Closer) *otelTracer {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", service))),
+	)
+
+	return &otelTracer{
+		provider:   provider,
+		tracer:     provider.Tracer("packs-api"),
+		propagator: propagation.TraceContext{},
+		out:        out,
+	}
+}
+
+func (t *otelTracer) Instrument(r *mux.Router) {
+	r.Use(t.middleware)
+	if r.NotFoundHandler == nil {
+		r.NotFoundHandler = http.NotFoundHandler()
+	}
+	r.NotFoundHandler = t.middleware(r.NotFoundHandler)
+	if r.MethodNotAllowedHandler == nil {
+		r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		})
+	}
+	r.MethodNotAllowedHandler = t.middleware(r.MethodNotAllowedHandler)
+}
+
+func (t *otelTracer) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown route"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		ctx := t.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := t.tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", sw.status))
+		if sw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.status))
+		}
+	})
+}
+
+func (t *otelTracer) Start(ctx context.Context, name, spanType string) (context.Context, Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(attribute.String("span.type", spanType)))
+	return ctx, otelSpan{span: span}
+}
+
+// RecordPanic records v as an exception event of the request's span and
+// fails it.
+func (t *otelTracer) RecordPanic(ctx context.Context, v interface{}) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(fmt.Errorf("panic: %v", v), trace.WithStackTrace(true))
+	span.SetStatus(codes.Error, "panic")
+}
+
+// LogFields uses the field names of apmlogrus, so log queries work with
+// either backend.
+func (t *otelTracer) LogFields(ctx context.Context) logrus.Fields {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return logrus.Fields{
+		"trace.id": sc.TraceID().String(),
+		"span.id":  sc.SpanID().String(),
+	}
+}
+
+func (t *otelTracer) Shutdown(ctx context.Context) error {
+	err := t.provider.Shutdown(ctx)
+	if t.out != nil {
+		err = errors.Join(err, t.out.Close())
+	}
+	return err
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s otelSpan) End() {
+	s.span.End()
+}
+
+// statusWriter remembers the status code written to the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the wrapped writer, e.g. to
+// flush it.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush keeps http.Flusher working for the handlers below the middleware.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over to the caller, if the wrapped writer can.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+	w.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestOTelTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := newOTelTracer(exporter, "packs-api", nil)
+
+	var fields logrus.Fields
+	router := mux.NewRouter()
+	tracer.Instrument(router)
+	router.HandleFunc("/api/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fields = tracer.LogFields(r.Context())
+		_, span := tracer.Start(r.Context(), "store.GetOrder", SpanTypeDB)
+		span.RecordError(errors.New("connection refused"))
+		span.End()
+		w.WriteHeader(http.StatusInternalServerError)
+	}).Methods(http.MethodGet)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/orders/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Nil(t, tracer.provider.ForceFlush(context.Background()))
+
+	spans := exporter.GetSpans()
+	assert.Equal(t, 2, len(spans))
+	store, server := spans[0], spans[1]
+
+	assert.Equal(t, "GET /api/orders/{id}", server.Name)
+	assert.Equal(t, codes.Error, server.Status.Code)
+	// The request continues the trace of its traceparent header
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+
+	assert.Equal(t, "store.GetOrder", store.Name)
+	assert.Equal(t, server.SpanContext.SpanID(), store.Parent.SpanID())
+	assert.Equal(t, codes.Error, store.Status.Code)
+	assert.Equal(t, "connection refused", store.Status.Description)
+
+	assert.Equal(t, logrus.Fields{
+		"trace.id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span.id":  server.SpanContext.SpanID().String(),
+	}, fields)
+	assert.Nil(t, tracer.LogFields(context.Background()))
+}
+
+func TestNew(t *testing.T) {
+	for _, exporter := range []string{ExporterNone, ExporterElastic, ExporterStdout} {
+		tracer, err := New(exporter, "", "packs-api")
+		assert.Nil(t, err, exporter)
+		assert.Nil(t, tracer.Shutdown(context.Background()), exporter)
+	}
+
+	_, err := New("jaeger", "", "packs-api")
+	assert.EqualError(t, err, `unknown tracing exporter "jaeger"`)
+}
+
+func TestStatusWriter_Flush(t *testing.T) {
+	rr := httptest.NewRecorder()
+	sw := &statusWriter{ResponseWriter: rr, status: http.StatusOK}
+
+	assert.Nil(t, http.NewResponseController(sw).Flush())
+	assert.True(t, rr.Flushed)
+
+	// httptest.ResponseRecorder cannot be hijacked
+	_, _, err := sw.Hijack()
+	assert.NotNil(t, err)
+	_, _, err = http.NewResponseController(sw).Hijack()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,123 @@
+// Package tracing starts spans with the backend chosen in the configuration,
+// so the handlers, the solver and the store do not depend on one.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+)
+
+// Exporters that can be passed to New.
+const (
+	// ExporterNone records nothing.
+	ExporterNone = "none"
+	// ExporterElastic sends transactions and spans to Elastic APM, set up
+	// with the ELASTIC_APM_* variables.
+	ExporterElastic = "elastic"
+	// ExporterOTLP sends OpenTelemetry spans over OTLP/HTTP, set up with
+	// the OTEL_EXPORTER_OTLP_* variables.
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes OpenTelemetry spans to stdout as JSON, for local
+	// use.
+	ExporterStdout = "stdout"
+	// ExporterFile writes OpenTelemetry spans to a file as JSON, for local
+	// use.
+	ExporterFile = "file"
+)
+
+// Span types passed to Tracer.Start.
+const (
+	SpanTypeSolver = "solver"
+	SpanTypeDB     = "db.mongodb"
+)
+
+// Tracer starts spans with one backend.
+type Tracer interface {
+	// Instrument starts a span for every request handled by r, named after
+	// the matched route template. It also wraps r.NotFoundHandler and
+	// r.MethodNotAllowedHandler, which must be set before.
+	Instrument(r *mux.Router)
+	// Start starts a span below the span in ctx and returns the context
+	// holding the new span.
+	Start(ctx context.Context, name, spanType string) (context.Context, Span)
+	// RecordPanic reports the recovered panic value v against the request
+	// whose span is in ctx.
+	RecordPanic(ctx context.Context, v interface{})
+	// LogFields returns the fields tying a log entry to the trace in ctx,
+	// nil when ctx has none.
+	LogFields(ctx context.Context) logrus.Fields
+	// Shutdown exports the spans that are still buffered.
+	Shutdown(ctx context.Context) error
+}
+
+// Span is a started span.
+type Span interface {
+	// RecordError marks the span as failed because of err.
+	RecordError(err error)
+	End()
+}
+
+// Noop records nothing.
+var Noop Tracer = noopTracer{}
+
+// New returns the Tracer for exporter, one of the Exporter constants. file
+// is where ExporterFile writes and service names the spans' service.
+func New(exporter, file, service string) (Tracer, error) {
+	switch exporter {
+	case ExporterNone:
+		return Noop, nil
+	case ExporterElastic:
+		return elasticTracer{}, nil
+	case ExporterOTLP:
+		exp, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("error creating OTLP exporter: %w", err)
+		}
+		return newOTelTracer(exp, service, nil), nil
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("error creating stdout exporter: %w", err)
+		}
+		return newOTelTracer(exp, service, nil), nil
+	case ExporterFile:
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("error opening trace file: %w", err)
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("error creating file exporter: %w", err)
+		}
+		return newOTelTracer(exp, service, f), nil
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", exporter)
+	}
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Instrument(*mux.Router) {}
+
+func (noopTracer) Start(ctx context.Context, _, _ string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+func (noopTracer) RecordPanic(context.Context, interface{}) {}
+
+func (noopTracer) LogFields(context.Context) logrus.Fields { return nil }
+
+func (noopTracer) Shutdown(context.Context) error { return nil }
+
+type noopSpan struct{}
+
+func (noopSpan) RecordError(error) {}
+
+func (noopSpan) End() {}
@@ -7,9 +7,11 @@ package mocks
 import (
 	context "context"
 	resources "packs-api/internal/resources"
+	store "packs-api/internal/store"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // MockNoSQLStore is a mock of NoSQLStore interface.
@@ -63,6 +65,20 @@ func (mr *MockNoSQLStoreMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockNoSQLStore)(nil).Close))
 }
 
+// CreateIdempotencyRecord mocks base method.
+func (m *MockNoSQLStore) CreateIdempotencyRecord(ctx context.Context, record *resources.IdempotencyRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdempotencyRecord", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIdempotencyRecord indicates an expected call of CreateIdempotencyRecord.
+func (mr *MockNoSQLStoreMockRecorder) CreateIdempotencyRecord(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdempotencyRecord", reflect.TypeOf((*MockNoSQLStore)(nil).CreateIdempotencyRecord), ctx, record)
+}
+
 // CreateOrder mocks base method.
 func (m *MockNoSQLStore) CreateOrder(ctx context.Context, order *resources.Order) error {
 	m.ctrl.T.Helper()
@@ -77,17 +93,118 @@ func (mr *MockNoSQLStoreMockRecorder) CreateOrder(ctx, order interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockNoSQLStore)(nil).CreateOrder), ctx, order)
 }
 
+// DeleteIdempotencyRecord mocks base method.
+func (m *MockNoSQLStore) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyRecord", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyRecord indicates an expected call of DeleteIdempotencyRecord.
+func (mr *MockNoSQLStoreMockRecorder) DeleteIdempotencyRecord(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyRecord", reflect.TypeOf((*MockNoSQLStore)(nil).DeleteIdempotencyRecord), ctx, key)
+}
+
+// DeleteOrder mocks base method.
+func (m *MockNoSQLStore) DeleteOrder(ctx context.Context, id primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrder", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOrder indicates an expected call of DeleteOrder.
+func (mr *MockNoSQLStoreMockRecorder) DeleteOrder(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrder", reflect.TypeOf((*MockNoSQLStore)(nil).DeleteOrder), ctx, id)
+}
+
+// GetAPIKey mocks base method.
+func (m *MockNoSQLStore) GetAPIKey(ctx context.Context, keyHash string) (*resources.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKey", ctx, keyHash)
+	ret0, _ := ret[0].(*resources.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey.
+func (mr *MockNoSQLStoreMockRecorder) GetAPIKey(ctx, keyHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockNoSQLStore)(nil).GetAPIKey), ctx, keyHash)
+}
+
 // GetAllOrders mocks base method.
-func (m *MockNoSQLStore) GetAllOrders(ctx context.Context) ([]*resources.Order, error) {
+func (m *MockNoSQLStore) GetAllOrders(ctx context.Context, query store.OrderQuery) ([]*resources.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllOrders", ctx)
+	ret := m.ctrl.Call(m, "GetAllOrders", ctx, query)
 	ret0, _ := ret[0].([]*resources.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllOrders indicates an expected call of GetAllOrders.
-func (mr *MockNoSQLStoreMockRecorder) GetAllOrders(ctx interface{}) *gomock.Call {
+func (mr *MockNoSQLStoreMockRecorder) GetAllOrders(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllOrders", reflect.TypeOf((*MockNoSQLStore)(nil).GetAllOrders), ctx, query)
+}
+
+// GetIdempotencyRecord mocks base method.
+func (m *MockNoSQLStore) GetIdempotencyRecord(ctx context.Context, key string) (*resources.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyRecord", ctx, key)
+	ret0, _ := ret[0].(*resources.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyRecord indicates an expected call of GetIdempotencyRecord.
+func (mr *MockNoSQLStoreMockRecorder) GetIdempotencyRecord(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyRecord", reflect.TypeOf((*MockNoSQLStore)(nil).GetIdempotencyRecord), ctx, key)
+}
+
+// GetOrder mocks base method.
+func (m *MockNoSQLStore) GetOrder(ctx context.Context, id primitive.ObjectID) (*resources.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrder", ctx, id)
+	ret0, _ := ret[0].(*resources.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrder indicates an expected call of GetOrder.
+func (mr *MockNoSQLStoreMockRecorder) GetOrder(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockNoSQLStore)(nil).GetOrder), ctx, id)
+}
+
+// UpdateIdempotencyRecord mocks base method.
+func (m *MockNoSQLStore) UpdateIdempotencyRecord(ctx context.Context, record *resources.IdempotencyRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIdempotencyRecord", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIdempotencyRecord indicates an expected call of UpdateIdempotencyRecord.
+func (mr *MockNoSQLStoreMockRecorder) UpdateIdempotencyRecord(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIdempotencyRecord", reflect.TypeOf((*MockNoSQLStore)(nil).UpdateIdempotencyRecord), ctx, record)
+}
+
+// UpdateOrder mocks base method.
+func (m *MockNoSQLStore) UpdateOrder(ctx context.Context, order *resources.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrder", ctx, order)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOrder indicates an expected call of UpdateOrder.
+func (mr *MockNoSQLStoreMockRecorder) UpdateOrder(ctx, order interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllOrders", reflect.TypeOf((*MockNoSQLStore)(nil).GetAllOrders), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrder", reflect.TypeOf((*MockNoSQLStore)(nil).UpdateOrder), ctx, order)
 }
@@ -1,11 +1,21 @@
 import { useState, useEffect } from 'react'
 import './App.css'
 
+interface Pack {
+  size: number;
+  quantity: number;
+}
+
 interface Order {
   id: string;
   items: number;
   packSizes: number[];
   packQuantity: Record<string, number>;
+  packs: Pack[];
+  totalPacks: number;
+  itemsShipped: number;
+  overage: number;
+  objective: string;
   createdAt: string;
   updatedAt: string;
 }
@@ -19,6 +29,10 @@ interface OrdersResponse {
   data: Order[];
 }
 
+interface OrderResponse {
+  data: Order;
+}
+
 function App() {
   const [orders, setOrders] = useState<Order[]>([]);
   const [isLoading, setIsLoading] = useState(true);
@@ -68,12 +82,14 @@ function App() {
         throw new Error(`HTTP error! status: ${response.status}`);
       }
 
+      const result: OrderResponse = await response.json();
+
       // Reset form
       setItemCount(0);
       setPackSizesInput('');
-      
-      // Refresh orders list
-      await fetchOrders();
+
+      // The list shows the newest orders first
+      setOrders(prev => [result.data, ...prev]);
     } catch (error) {
       setSubmitError(error instanceof Error ? error.message : 'Failed to submit order');
     } finally {
@@ -84,7 +100,8 @@ function App() {
   const fetchOrders = async () => {
     try {
       setIsLoading(true);
-      const response = await fetch(`${API_BASE_URL}/api/orders`);
+      // Only the first page is shown, so it holds the newest orders
+      const response = await fetch(`${API_BASE_URL}/api/orders?sort=-createdAt`);
       
       if (!response.ok) {
         throw new Error(`HTTP error! status: ${response.status}`);
@@ -157,6 +174,8 @@ function App() {
               <th>Items</th>
               <th>Pack Sizes</th>
               <th>Pack Quantity</th>
+              <th>Total Packs</th>
+              <th>Overage</th>
               <th>Created At</th>
               <th>Updated At</th>
             </tr>
@@ -168,10 +187,12 @@ function App() {
                 <td>{order.items}</td>
                 <td>{order.packSizes.join(', ')}</td>
                 <td>
-                  {Object.entries(order.packQuantity).map(([size, quantity]) => (
+                  {(order.packs ?? []).map(({ size, quantity }) => (
                     `${size}: ${quantity}`
                   )).join(', ')}
                 </td>
+                <td>{order.totalPacks}</td>
+                <td>{order.overage}</td>
                 <td>{new Date(order.createdAt).toLocaleString()}</td>
                 <td>{new Date(order.updatedAt).toLocaleString()}</td>
               </tr>